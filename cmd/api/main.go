@@ -1,40 +1,306 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ericoliveiras/ecoponto-api/internal/auth"
 	"github.com/ericoliveiras/ecoponto-api/internal/config"
 	"github.com/ericoliveiras/ecoponto-api/internal/database"
 	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/ericoliveiras/ecoponto-api/internal/geocoding"
+	"github.com/ericoliveiras/ecoponto-api/internal/health"
+	"github.com/ericoliveiras/ecoponto-api/internal/item"
+	"github.com/ericoliveiras/ecoponto-api/internal/job"
+	"github.com/ericoliveiras/ecoponto-api/internal/logging"
+	"github.com/ericoliveiras/ecoponto-api/internal/mail"
+	"github.com/ericoliveiras/ecoponto-api/internal/metrics"
+	"github.com/ericoliveiras/ecoponto-api/internal/organizacao"
 	"github.com/ericoliveiras/ecoponto-api/internal/server"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/tracing"
 	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Logs em JSON no stdout; o nível vem da configuração logo abaixo
+	logLevel := new(slog.LevelVar)
+	slog.SetDefault(logging.New(os.Stdout, logLevel))
+
 	// 1. Carrega as configurações
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Erro ao carregar configuração: %v", err)
+		fatal("Erro ao carregar configuração", err)
+	}
+	logLevel.Set(cfg.LogLevel)
+	if os.Getenv(gin.EnvGinMode) == "" {
+		// Sem as mensagens de debug do Gin, que não saem em JSON
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// O SIGTERM (deploy do container) ou o Ctrl+C iniciam o desligamento gracioso
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Traces (OpenTelemetry): o propagador W3C sempre; o envio só com OTEL_EXPORTER_OTLP_ENDPOINT
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Endpoint:    cfg.OTLPEndpoint,
+		ServiceName: cfg.TracingServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("Erro ao configurar o OpenTelemetry", err)
 	}
 
-	// 2. Conecta ao banco
+	// 2. Conecta ao banco (fechado só no fim do desligamento)
 	db := database.Connect(cfg.DatabaseURL)
-	defer db.Close()
+
+	// Chaves do JWT: RS256/EdDSA a partir de arquivos PEM, ou HS256 com o JWT_SECRET
+	jwtKeys, err := auth.NewKeySet(auth.KeyOptions{
+		Secret:         cfg.JWTSecret,
+		SigningKeyFile: cfg.JWTSigningKeyFile,
+		VerifyKeyFiles: cfg.JWTVerifyKeyFiles,
+		AcceptHS256:    cfg.JWTAcceptHS256,
+	})
+	if err != nil {
+		fatal("Erro ao carregar as chaves do JWT", err)
+	}
 
 	// 3. Cria os repositórios
 	ecopontoRepo := ecoponto.NewRepository(db)
 	userRepo := user.NewRepository(db) //
+	itemRepo := item.NewRepository(db)
+	orgRepo := organizacao.NewRepository(db)
+	tenantRepo := tenant.NewRepository(db)
+	jobRepo := job.NewRepository(db)
+	sessionRepo := auth.NewSessionRepository(db)
+	resetRepo := auth.NewResetRepository(db)
+	attemptRepo := auth.NewAttemptRepository(db)
+	apiKeyRepo := auth.NewAPIKeyRepository(db)
+	mfaRepo := auth.NewMFARepository(db)
+
+	// 4. Cria o geocoder do provedor configurado
+	geocoder, err := geocoding.New(geocoding.Options{
+		Provider:   cfg.GeocoderProvider,
+		BaseURL:    cfg.GeocoderURL,
+		UserAgent:  cfg.GeocoderUserAgent,
+		StaticFile: cfg.GeocoderStaticFile,
+		Client: geocoding.ClientOptions{
+			Timeout:          cfg.GeocoderTimeout,
+			RateLimit:        cfg.GeocoderRateLimit,
+			MaxRetries:       cfg.GeocoderMaxRetries,
+			BreakerThreshold: cfg.GeocoderBreakerThreshold,
+			BreakerCooldown:  cfg.GeocoderBreakerCooldown,
+		},
+	})
+	if err != nil {
+		fatal("Erro ao configurar o geocoding", err)
+	}
+
+	// Métricas do Prometheus: pool do banco, chamadas ao provedor de geocoding e números do domínio
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(db)
+	appMetrics.Register(metrics.NewDomainCollector(ecopontoRepo, jobRepo))
+	geocoder = appMetrics.InstrumentGeocoder(geocoder, cfg.GeocoderProvider)
+
+	// O cache no banco é consultado antes de qualquer chamada ao provedor
+	geocodingCacheRepo := geocoding.NewCacheRepository(db)
+	geocoder = geocoding.NewCached(geocoder, geocodingCacheRepo, cfg.GeocoderProvider,
+		cfg.GeocoderCacheTTL, cfg.GeocoderCacheNegativeTTL)
+
+	// E-mails (redefinição de senha): SMTP em produção, log ou arquivo só com MAIL_DEV=true
+	mailer, err := mail.New(mail.Options{
+		Driver:       cfg.MailDriver,
+		From:         cfg.MailFrom,
+		Dir:          cfg.MailDir,
+		Dev:          cfg.MailDev,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUser:     cfg.SMTPUser,
+		SMTPPassword: cfg.SMTPPassword,
+		SMTPTimeout:  cfg.SMTPTimeout,
+	})
+	if err != nil {
+		fatal("Erro ao configurar o envio de e-mails", err)
+	}
 
-	// Agora criamos os handlers, injetando os repositórios
-	ecopontoHandler := ecoponto.NewHandler(ecopontoRepo)
-	authHandler := auth.NewHandler(userRepo, cfg.JWTSecret)
+	// Os workers geocodificam as importações em lote em segundo plano.
+	// Têm um contexto próprio: no sinal de desligamento terminam o item atual
+	// (Stop) e só são interrompidos se o prazo do desligamento acabar.
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+	workerDone := make(chan struct{})
+	stopWorkers := func() {}
+	if cfg.JobWorkers > 0 {
+		worker := job.NewWorker(jobRepo, ecopontoRepo, geocoder, job.WorkerOptions{
+			Workers:       cfg.JobWorkers,
+			PollInterval:  cfg.JobPollInterval,
+			MaxTentativas: cfg.JobMaxTentativas,
+		})
+		stopWorkers = worker.Stop
+		go func() {
+			defer close(workerDone)
+			worker.Run(workerCtx)
+		}()
+	} else {
+		close(workerDone)
+	}
 
-	// Passamos o novo authHandler para o servidor
-	srv := server.NewServer(ecopontoHandler, authHandler, cfg.JWTSecret)
+	// 5. Agora criamos os handlers, injetando os repositórios
+	ecopontoHandler := ecoponto.NewHandler(ecopontoRepo, geocoder)
+	loginGuard := auth.NewLoginGuard(attemptRepo, auth.GuardOptions{
+		Janela:           cfg.LoginJanela,
+		TentativasLivres: cfg.LoginTentativasLivres,
+		AtrasoBase:       cfg.LoginAtrasoBase,
+		MaxFalhasConta:   cfg.LoginMaxFalhasConta,
+		MaxFalhasIP:      cfg.LoginMaxFalhasIP,
+		Bloqueio:         cfg.LoginBloqueio,
+	})
+	// Apaga as tentativas mais antigas que LOGIN_RETENCAO (para no sinal de desligamento)
+	go loginGuard.RunRetention(ctx, cfg.LoginRetencao)
+	for _, role := range cfg.MFARequiredRoles {
+		if !auth.ValidRole(role) {
+			fatal("MFA_REQUIRED_ROLES inválido", fmt.Errorf("papel desconhecido %q", role))
+		}
+	}
+	mfaOpts := auth.MFAOptions{
+		Issuer:        cfg.MFAIssuer,
+		RequiredRoles: cfg.MFARequiredRoles,
+		ChallengeTTL:  cfg.MFAChallengeTTL,
+		EnrollmentTTL: cfg.MFACadastroTTL,
+	}
+	authHandler := auth.NewHandler(userRepo, sessionRepo, loginGuard, jwtKeys, mfaRepo, mfaOpts, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	mfaHandler := auth.NewMFAHandler(userRepo, mfaRepo, sessionRepo, mfaOpts)
+	userHandler := auth.NewUserHandler(userRepo, sessionRepo, attemptRepo)
+	apiKeyHandler := auth.NewAPIKeyHandler(apiKeyRepo)
 
-	// 5. Sobe o servidor
-	if err := srv.Run(cfg.APIPort); err != nil {
-		log.Fatalf("Erro ao iniciar o servidor: %v", err)
+	// Login pelo provedor de identidade da prefeitura (opcional)
+	var oidcHandler *auth.OIDCHandler
+	if cfg.OIDCIssuer != "" {
+		oidcHandler = auth.NewOIDCHandler(authHandler, auth.NewOIDCStore(db), auth.OIDCOptions{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+			SuccessURL:   cfg.OIDCSuccessURL,
+		})
 	}
+
+	resetHandler := auth.NewResetHandler(userRepo, resetRepo, sessionRepo, mailer, auth.ResetOptions{
+		URL:       cfg.PasswordResetURL,
+		TTL:       cfg.PasswordResetTTL,
+		Intervalo: cfg.PasswordResetIntervalo,
+	})
+	itemHandler := item.NewHandler(itemRepo, ecopontoRepo)
+	orgHandler := organizacao.NewHandler(orgRepo, ecopontoRepo)
+	tenantHandler := tenant.NewHandler()
+	geocodingHandler := geocoding.NewHandler(geocoder, geocodingCacheRepo, server.PlatformOperator(cfg.DefaultTenant))
+	jobHandler := job.NewHandler(jobRepo)
+
+	// Dependências verificadas pelo /readyz. O geocoding só é crítico se configurado:
+	// sem ele a API continua servindo as consultas públicas.
+	healthHandler := health.NewHandler(db, health.NewChecker(cfg.HealthCheckTimeout,
+		health.Check{Nome: "database", Critico: true, Run: health.Database(db)},
+		health.Check{Nome: "postgis", Critico: true, Run: health.PostGIS(db)},
+		health.Check{Nome: "migrations", Critico: true, Run: health.Migrations(db)},
+		health.Check{Nome: "geocoder", Critico: cfg.HealthGeocoderCritico,
+			Run: health.Cached(cfg.HealthGeocoderCache, health.Geocoder(geocoder))},
+	))
+
+	// Passamos os handlers para o servidor
+	srv, err := server.NewServer(server.Deps{
+		EcopontoHandler:    ecopontoHandler,
+		AuthHandler:        authHandler,
+		UserHandler:        userHandler,
+		ResetHandler:       resetHandler,
+		ItemHandler:        itemHandler,
+		OrganizacaoHandler: orgHandler,
+		TenantHandler:      tenantHandler,
+		GeocodingHandler:   geocodingHandler,
+		JobHandler:         jobHandler,
+		HealthHandler:      healthHandler,
+		Metrics:            appMetrics,
+		MetricsToken:       cfg.MetricsToken,
+		TenantRepo:         tenantRepo,
+		SessionRepo:        sessionRepo,
+		APIKeyRepo:         apiKeyRepo,
+		APIKeyHandler:      apiKeyHandler,
+		MFAHandler:         mfaHandler,
+		OIDCHandler:        oidcHandler,
+		JWTKeys:            jwtKeys,
+		DefaultTenant:      cfg.DefaultTenant,
+		TrustedProxies:     cfg.TrustedProxies,
+		ForgotPorIP:        cfg.PasswordResetPorIP,
+		ServiceName:        cfg.TracingServiceName,
+	})
+	if err != nil {
+		fatal("Erro ao configurar o servidor", err)
+	}
+
+	// Todo o desligamento (requisições, workers e e-mails) cabe num único
+	// SHUTDOWN_TIMEOUT, contado a partir do sinal; os workers param junto com o HTTP
+	shutdownDeadline := make(chan time.Time, 1)
+	context.AfterFunc(ctx, func() {
+		shutdownDeadline <- time.Now().Add(cfg.ShutdownTimeout)
+		stopWorkers()
+	})
+
+	// 6. Sobe o servidor e espera o sinal de desligamento
+	err = srv.Run(ctx, server.HTTPOptions{
+		Port:              cfg.APIPort,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+		ShutdownTimeout:   cfg.ShutdownTimeout,
+		TLSCertFile:       cfg.TLSCertFile,
+		TLSKeyFile:        cfg.TLSKeyFile,
+	})
+	if err != nil && ctx.Err() == nil {
+		fatal("Erro ao iniciar o servidor", err)
+	}
+	if err != nil {
+		slog.Error("Erro no desligamento do servidor", "erro", err)
+	}
+	stop() // Um segundo sinal encerra o processo na hora
+
+	// 7. Espera os workers terminarem o item atual e os e-mails em segundo plano
+	// saírem, no que resta do prazo
+	waitCtx, cancelWait := context.WithDeadline(context.Background(), <-shutdownDeadline)
+	defer cancelWait()
+	select {
+	case <-workerDone:
+	case <-waitCtx.Done():
+		// Prazo esgotado: interrompe os itens (voltam para a fila; a importação é idempotente)
+		slog.Warn("Workers de jobs não pararam no prazo", "prazo", cfg.ShutdownTimeout.String())
+		cancelWorkers()
+	}
+	if err := resetHandler.Wait(waitCtx); err != nil {
+		slog.Warn("E-mails de redefinição de senha ainda em envio no fim do prazo", "erro", err)
+	}
+
+	// 8. Envia os spans pendentes
+	if err := shutdownTracing(waitCtx); err != nil {
+		slog.Error("Erro ao enviar os últimos traces", "erro", err)
+	}
+
+	// 9. Fecha o banco por último, quando ninguém mais o usa
+	if err := db.Close(); err != nil {
+		slog.Error("Erro ao fechar o banco de dados", "erro", err)
+	}
+	slog.Info("Servidor desligado")
+}
+
+// fatal registra o erro e encerra o processo
+func fatal(msg string, err error) {
+	slog.Error(msg, "erro", err)
+	os.Exit(1)
 }
@@ -0,0 +1,35 @@
+// oidc-mock é um provedor OpenID Connect mínimo para desenvolvimento e testes
+// do login OIDC da API, sem depender do provedor de identidade da prefeitura.
+//
+// Suporta descoberta, authorization code + PKCE (S256), ID token RS256 e JWKS.
+// Não use em produção: qualquer e-mail digitado é aceito.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/oidcmock"
+)
+
+func main() {
+	// 1. Configuração
+	port := os.Getenv("MOCK_OIDC_PORT")
+	if port == "" {
+		port = "9000"
+	}
+	issuer := os.Getenv("MOCK_OIDC_ISSUER")
+	if issuer == "" {
+		issuer = "http://localhost:" + port
+	}
+
+	// 2. Cria o provedor (a chave de assinatura é nova a cada execução)
+	p, err := oidcmock.New(issuer)
+	if err != nil {
+		log.Fatalf("Erro ao gerar a chave: %v", err)
+	}
+
+	log.Printf("oidc-mock em %s (issuer %s)", ":"+port, issuer)
+	log.Fatal(http.ListenAndServe(":"+port, p))
+}
@@ -52,14 +52,19 @@ func main() {
 	hashString := string(hash)
 	log.Println("Hash gerado com sucesso.")
 
-	// 5. Inserir o usuário no banco
+	// 5. Inserir o usuário no banco, no município informado (ou no padrão)
+	tenantSlug := os.Getenv("DEFAULT_TENANT")
+	if tenantSlug == "" {
+		tenantSlug = "default"
+	}
+
 	query := `
-		INSERT INTO users (email, password_hash)
-		VALUES ($1, $2)
-		ON CONFLICT (email) DO NOTHING
+		INSERT INTO users (tenant_id, email, password_hash, role)
+		SELECT id, $2, $3, 'superadmin' FROM tenants WHERE slug = $1
+		ON CONFLICT (tenant_id, email) DO NOTHING
 	`
 
-	res, err := db.Exec(query, AdminEmail, hashString)
+	res, err := db.Exec(query, tenantSlug, AdminEmail, hashString)
 	if err != nil {
 		log.Fatalf("Erro ao inserir admin: %v", err)
 	}
@@ -67,7 +72,7 @@ func main() {
 	// 6. Checar o resultado
 	rows, _ := res.RowsAffected()
 	if rows == 0 {
-		log.Printf("Usuário '%s' já existia. Nada foi feito.", AdminEmail)
+		log.Printf("Usuário '%s' já existia (ou o município '%s' não existe). Nada foi feito.", AdminEmail, tenantSlug)
 	} else {
 		log.Printf("Usuário admin '%s' criado com sucesso!", AdminEmail)
 	}
@@ -0,0 +1,51 @@
+package apierror
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// MensagemInterna é o que o cliente recebe num erro 500: o detalhe fica só no log
+const MensagemInterna = "Erro interno do servidor"
+
+// Respond envia o erro no formato padrão da API: {"error": "...", "request_id": "..."}
+func Respond(c *gin.Context, status int, msg string) {
+	c.JSON(status, With(c, gin.H{"error": msg}))
+}
+
+// Abort envia o erro e interrompe a cadeia de middlewares
+func Abort(c *gin.Context, status int, msg string) {
+	c.AbortWithStatusJSON(status, With(c, gin.H{"error": msg}))
+}
+
+// Internal registra o erro no log e responde 500 com uma mensagem genérica,
+// para detalhes do banco ou de serviços externos não vazarem ao cliente
+func Internal(c *gin.Context, err error) {
+	logInternal(c, err)
+	Respond(c, http.StatusInternalServerError, MensagemInterna)
+}
+
+// AbortInternal é o Internal dos middlewares
+func AbortInternal(c *gin.Context, err error) {
+	logInternal(c, err)
+	Abort(c, http.StatusInternalServerError, MensagemInterna)
+}
+
+// With acrescenta o request_id a uma resposta de erro com campos extras
+func With(c *gin.Context, body gin.H) gin.H {
+	if id := logging.RequestID(c.Request.Context()); id != "" {
+		body["request_id"] = id
+	}
+	return body
+}
+
+func logInternal(c *gin.Context, err error) {
+	slog.ErrorContext(c.Request.Context(), "Erro interno",
+		"erro", err,
+		"method", c.Request.Method,
+		"route", c.FullPath(),
+	)
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// apiKeyPrefix identifica as chaves desta API (ajuda scanners de segredos a encontrá-las)
+const apiKeyPrefix = "eco_"
+
+// ErrAPIKeyInvalida é retornado quando a chave não existe, expirou ou foi revogada
+var ErrAPIKeyInvalida = errors.New("chave de API inválida, expirada ou revogada")
+
+// APIKey é uma chave de API de um cliente máquina-a-máquina
+type APIKey struct {
+	ID         string         `db:"id" json:"id"`
+	TenantID   string         `db:"tenant_id" json:"-"`
+	Nome       string         `db:"nome" json:"nome"`
+	Prefixo    string         `db:"prefixo" json:"prefixo"` // Início da chave, para identificá-la
+	KeyHash    string         `db:"key_hash" json:"-"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time     `db:"expires_at" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"last_used_at,omitempty"`
+	CreatedBy  *string        `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	RevokedAt  *time.Time     `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+const apiKeyColumns = `id, tenant_id, nome, prefixo, key_hash, scopes, expires_at, last_used_at, created_by, created_at, revoked_at`
+
+// newAPIKey gera uma chave no formato eco_<prefixo>_<segredo>.
+// Retorna a chave completa (mostrada uma única vez), o prefixo e o hash.
+func newAPIKey() (key, prefixo, hash string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	prefixo = apiKeyPrefix + hex.EncodeToString(id)
+	key = prefixo + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefixo, hashToken(key), nil
+}
+
+// looksLikeAPIKey indica se o valor tem o formato das nossas chaves
+func looksLikeAPIKey(v string) bool {
+	return strings.HasPrefix(v, apiKeyPrefix)
+}
+
+// APIKeyRepository gerencia a persistência das chaves de API
+type APIKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewAPIKeyRepository cria uma nova instância do repositório
+func NewAPIKeyRepository(db *sqlx.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// Create grava uma nova chave
+func (r *APIKeyRepository) Create(ctx context.Context, k APIKey) (*APIKey, error) {
+	var created APIKey
+	err := r.db.QueryRowxContext(ctx, `
+		INSERT INTO api_keys (tenant_id, nome, prefixo, key_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+apiKeyColumns,
+		k.TenantID, k.Nome, k.Prefixo, k.KeyHash, k.Scopes, k.ExpiresAt, k.CreatedBy,
+	).StructScan(&created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// List lista as chaves do município (inclusive as revogadas, para auditoria)
+func (r *APIKeyRepository) List(ctx context.Context, tenantID string) ([]APIKey, error) {
+	var keys []APIKey
+	err := r.db.SelectContext(ctx, &keys, `
+		SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = make([]APIKey, 0)
+	}
+	return keys, nil
+}
+
+// Revoke revoga a chave; ela deixa de ser aceita imediatamente
+func (r *APIKeyRepository) Revoke(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND tenant_id = $2 AND revoked_at IS NULL`, id, tenantID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Authenticate busca uma chave válida do município pelo valor apresentado
+// e registra o uso (no máximo uma escrita por minuto por chave)
+func (r *APIKeyRepository) Authenticate(ctx context.Context, tenantID, key string) (*APIKey, error) {
+	var k APIKey
+	err := r.db.GetContext(ctx, &k, `
+		SELECT `+apiKeyColumns+` FROM api_keys
+		WHERE
+			key_hash = $1
+			AND tenant_id = $2
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())`, hashToken(key), tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyInvalida
+		}
+		return nil, err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`, k.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
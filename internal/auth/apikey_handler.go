@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// APIKeyHandler gerencia as requisições de administração das chaves de API
+type APIKeyHandler struct {
+	repo *APIKeyRepository
+}
+
+// NewAPIKeyHandler cria uma nova instância do handler
+func NewAPIKeyHandler(repo *APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+// CreateAPIKeyRequest é o corpo de POST /api/apikeys
+type CreateAPIKeyRequest struct {
+	Nome      string     `json:"nome" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"` // Opcional; sem ele a chave não expira
+}
+
+// ListAPIKeys é o método para o endpoint GET /api/apikeys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.repo.List(c.Request.Context(), tenant.IDFromContext(c))
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey é o método para o endpoint POST /api/apikeys.
+// A chave completa só aparece nesta resposta; depois, apenas o prefixo.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	// 1. Faz o "bind" do JSON
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Valida os scopes e a validade
+	for _, s := range req.Scopes {
+		if !ValidAPIKeyScope(Permission(s)) {
+			apierror.Respond(c, http.StatusBadRequest, "Scope inválido: "+s)
+			return
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		apierror.Respond(c, http.StatusBadRequest, "expires_at deve estar no futuro")
+		return
+	}
+
+	// 3. Gera a chave (só o hash vai para o banco)
+	key, prefixo, hash, err := newAPIKey()
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	var createdBy *string
+	if userID := UserIDFromContext(c); userID != "" {
+		createdBy = &userID
+	}
+
+	// 4. Chama o repositório
+	k, err := h.repo.Create(c.Request.Context(), APIKey{
+		TenantID:  tenant.IDFromContext(c),
+		Nome:      req.Nome,
+		Prefixo:   prefixo,
+		KeyHash:   hash,
+		Scopes:    pq.StringArray(req.Scopes),
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: createdBy,
+	})
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 5. Retorna a chave criada e o valor completo, que não poderá ser consultado depois
+	c.JSON(http.StatusCreated, gin.H{"api_key": k, "chave": key})
+}
+
+// RevokeAPIKey é o método para o endpoint DELETE /api/apikeys/:id
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	err := h.repo.Revoke(c.Request.Context(), tenant.IDFromContext(c), c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Chave não encontrada ou já revogada")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefixo, hash, err := newAPIKey()
+	if err != nil {
+		t.Fatalf("newAPIKey: %v", err)
+	}
+
+	// eco_<8 hex>_<segredo>: o prefixo identifica a chave e só o hash é guardado
+	if !looksLikeAPIKey(key) || !strings.HasPrefix(key, prefixo+"_") || len(prefixo) != len(apiKeyPrefix)+8 {
+		t.Errorf("formato inesperado: chave %q, prefixo %q", key, prefixo)
+	}
+	if hash != hashToken(key) || strings.Contains(hash, key) {
+		t.Errorf("hash %q não corresponde à chave", hash)
+	}
+
+	outra, _, _, err := newAPIKey()
+	if err != nil || outra == key {
+		t.Errorf("chaves repetidas: %q", outra)
+	}
+}
+
+func TestAPIKeyFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+	}{
+		{"X-API-Key", map[string]string{"X-API-Key": "eco_abc_123"}, "eco_abc_123"},
+		{"Bearer com chave", map[string]string{"Authorization": "Bearer eco_abc_123"}, "eco_abc_123"},
+		{"Bearer com JWT", map[string]string{"Authorization": "Bearer eyJhbGciOi.x.y"}, ""},
+		{"sem credenciais", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				c.Request.Header.Set(k, v)
+			}
+			if got := APIKeyFromRequest(c); got != tt.want {
+				t.Errorf("APIKeyFromRequest = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidAPIKeyScope(t *testing.T) {
+	// Gerenciar usuários e chaves fica restrito a pessoas
+	for _, p := range []Permission{PermUsuariosGerenciar, PermAPIKeysGerenciar, "permissao:inexistente"} {
+		if ValidAPIKeyScope(p) {
+			t.Errorf("%s não deveria ser aceito como scope", p)
+		}
+	}
+	for _, p := range []Permission{PermEcopontosLer, PermEcopontosCriar, PermGeocodingUsar} {
+		if !ValidAPIKeyScope(p) {
+			t.Errorf("%s deveria ser aceito como scope", p)
+		}
+	}
+}
+
+func TestCreateAPIKeyRejectsHumanOnlyScopes(t *testing.T) {
+	db, _ := newMockDB(t)
+	r := newTestRouter()
+	r.POST("/api/apikeys", NewAPIKeyHandler(NewAPIKeyRepository(db)).CreateAPIKey)
+
+	// Nenhuma chave é gravada
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/apikeys",
+		strings.NewReader(`{"nome": "integração", "scopes": ["ecopontos:ler", "usuarios:gerenciar"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	db, mock := newMockDB(t)
+	repo := NewAPIKeyRepository(db)
+	key, prefixo, hash, err := newAPIKey()
+	if err != nil {
+		t.Fatalf("newAPIKey: %v", err)
+	}
+
+	// 1. Chave válida do município: busca pelo hash e registra o uso
+	mock.ExpectQuery(`FROM api_keys\s+WHERE\s+key_hash = \$1\s+AND tenant_id = \$2\s+AND revoked_at IS NULL`).
+		WithArgs(hash, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id", "nome", "prefixo", "key_hash", "scopes", "expires_at", "last_used_at", "created_by", "created_at", "revoked_at"}).
+			AddRow("55555555-5555-5555-5555-555555555555", testTenantID, "integração", prefixo, hash, "{ecopontos:ler}", nil, nil, nil, time.Now(), nil))
+	mock.ExpectExec(`UPDATE api_keys SET last_used_at = NOW\(\)`).
+		WithArgs("55555555-5555-5555-5555-555555555555").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	k, err := repo.Authenticate(t.Context(), testTenantID, key)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if len(k.Scopes) != 1 || k.Scopes[0] != string(PermEcopontosLer) {
+		t.Errorf("scopes = %v", k.Scopes)
+	}
+
+	// 2. Chave revogada, expirada ou de outro município: a busca não encontra nada
+	mock.ExpectQuery(`FROM api_keys`).
+		WithArgs(hash, testTenantID).
+		WillReturnError(sql.ErrNoRows)
+
+	if _, err := repo.Authenticate(t.Context(), testTenantID, key); !errors.Is(err, ErrAPIKeyInvalida) {
+		t.Errorf("erro = %v, esperado ErrAPIKeyInvalida", err)
+	}
+}
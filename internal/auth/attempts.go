@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Resultados de uma tentativa de login
+const (
+	ResultadoSucesso            = "sucesso"
+	ResultadoSenhaInvalida      = "senha_invalida"
+	ResultadoUsuarioInexistente = "usuario_inexistente"
+	ResultadoDesativado         = "desativado"
+	ResultadoEmAndamento        = "em_andamento"        // Reservada; conta como falha até o resultado ser gravado
+	ResultadoBloqueado          = "bloqueado"           // Recusada sem conferir a senha
+	ResultadoDesbloqueio        = "desbloqueio"         // Um admin desbloqueou a conta
+	ResultadoAguardando2FA      = "aguardando_2fa"      // Senha correta; falta o segundo fator
+	ResultadoCodigo2FAInvalido  = "codigo_2fa_invalido" // Código TOTP ou de recuperação errado
+)
+
+// LoginAttempt é um registro da auditoria de logins
+type LoginAttempt struct {
+	ID        int64     `db:"id" json:"id"`
+	TenantID  string    `db:"tenant_id" json:"-"`
+	Email     string    `db:"email" json:"email"`
+	UserID    *string   `db:"user_id" json:"user_id,omitempty"`
+	IP        string    `db:"ip" json:"ip"`
+	UserAgent *string   `db:"user_agent" json:"user_agent,omitempty"`
+	Resultado string    `db:"resultado" json:"resultado"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// failureStats resume as falhas recentes de uma conta ou de um IP
+type failureStats struct {
+	Falhas int          `db:"falhas"`
+	Ultima sql.NullTime `db:"ultima"`
+}
+
+// AttemptRepository gerencia a auditoria das tentativas de login
+type AttemptRepository struct {
+	db *sqlx.DB
+}
+
+// NewAttemptRepository cria uma nova instância do repositório
+func NewAttemptRepository(db *sqlx.DB) *AttemptRepository {
+	return &AttemptRepository{db: db}
+}
+
+// Record grava uma tentativa
+func (r *AttemptRepository) Record(ctx context.Context, a LoginAttempt) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO login_attempts (tenant_id, email, user_id, ip, user_agent, resultado)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		a.TenantID, a.Email, a.UserID, a.IP, a.UserAgent, a.Resultado)
+	return err
+}
+
+// Reserve registra a tentativa antes de a senha (ou o código) ser conferida.
+// As tentativas da mesma conta e do mesmo IP são serializadas por advisory locks,
+// então uma rajada de requisições paralelas não passa toda pela verificação antes
+// de a primeira falha ser gravada: cada uma já enxerga as reservas anteriores
+// (que contam como falha até Finish). decide calcula a espera a partir das falhas;
+// com espera, a tentativa é gravada como "bloqueado" e a senha nem é conferida.
+func (r *AttemptRepository) Reserve(ctx context.Context, a LoginAttempt, since time.Time, decide func(conta, porIP failureStats) time.Duration) (int64, time.Duration, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	// 1. Trava a conta e depois o IP (sempre nessa ordem) até o commit
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('login:conta:' || $1 || ':' || $2, 0))`, a.TenantID, a.Email); err != nil {
+		return 0, 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('login:ip:' || $1, 0))`, a.IP); err != nil {
+		return 0, 0, err
+	}
+
+	// 2. Conta as falhas (incluindo as reservas ainda em andamento)
+	conta, err := accountFailures(ctx, tx, a.TenantID, a.Email, since)
+	if err != nil {
+		return 0, 0, err
+	}
+	porIP, err := ipFailures(ctx, tx, a.IP, since)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// 3. Grava a reserva (ou a recusa)
+	wait := decide(conta, porIP)
+	a.Resultado = ResultadoEmAndamento
+	if wait > 0 {
+		a.Resultado = ResultadoBloqueado
+	}
+	var id int64
+	err = tx.GetContext(ctx, &id, `
+		INSERT INTO login_attempts (tenant_id, email, user_id, ip, user_agent, resultado)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		a.TenantID, a.Email, a.UserID, a.IP, a.UserAgent, a.Resultado)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, wait, tx.Commit()
+}
+
+// Finish grava o resultado de uma tentativa reservada
+func (r *AttemptRepository) Finish(ctx context.Context, a LoginAttempt) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE login_attempts SET resultado = $2, user_id = COALESCE($3, user_id)
+		WHERE id = $1`, a.ID, a.Resultado, a.UserID)
+	return err
+}
+
+// PurgeBefore apaga as tentativas anteriores à data (retenção da auditoria)
+func (r *AttemptRepository) PurgeBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// accountFailures conta as falhas da conta desde o último login bem-sucedido
+// (ou desbloqueio), dentro da janela
+func accountFailures(ctx context.Context, q sqlx.QueryerContext, tenantID, email string, since time.Time) (failureStats, error) {
+	var st failureStats
+	err := sqlx.GetContext(ctx, q, &st, `
+		SELECT COUNT(*) AS falhas, MAX(created_at) AS ultima
+		FROM login_attempts
+		WHERE
+			tenant_id = $1
+			AND email = $2
+			AND resultado IN ('em_andamento', 'senha_invalida', 'usuario_inexistente', 'codigo_2fa_invalido')
+			AND created_at > $3
+			AND created_at > COALESCE((
+				SELECT MAX(created_at) FROM login_attempts
+				WHERE tenant_id = $1 AND email = $2 AND resultado IN ('sucesso', 'desbloqueio')
+			), '-infinity')`, tenantID, email, since)
+	return st, err
+}
+
+// ipFailures conta as falhas vindas do IP dentro da janela, em qualquer conta
+func ipFailures(ctx context.Context, q sqlx.QueryerContext, ip string, since time.Time) (failureStats, error) {
+	var st failureStats
+	err := sqlx.GetContext(ctx, q, &st, `
+		SELECT COUNT(*) AS falhas, MAX(created_at) AS ultima
+		FROM login_attempts
+		WHERE
+			ip = $1
+			AND resultado IN ('em_andamento', 'senha_invalida', 'usuario_inexistente', 'codigo_2fa_invalido')
+			AND created_at > $2`, ip, since)
+	return st, err
+}
+
+// ListByEmail lista as tentativas mais recentes de uma conta
+func (r *AttemptRepository) ListByEmail(ctx context.Context, tenantID, email string, limite int) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	err := r.db.SelectContext(ctx, &attempts, `
+		SELECT id, tenant_id, email, user_id, ip, user_agent, resultado, created_at
+		FROM login_attempts
+		WHERE tenant_id = $1 AND email = $2
+		ORDER BY created_at DESC
+		LIMIT $3`, tenantID, email, limite)
+	if err != nil {
+		return nil, err
+	}
+	if attempts == nil {
+		attempts = make([]LoginAttempt, 0)
+	}
+	return attempts, nil
+}
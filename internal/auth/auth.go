@@ -2,15 +2,18 @@ package auth
 
 import (
 	"errors"
-	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ErrTokenInvalido é devolvido quando o access token não passa na validação
+var ErrTokenInvalido = errors.New("token inválido")
+
 // ValidateToken extrai e valida o token JWT do header
-func ValidateToken(c *gin.Context, jwtSecret string) (*jwt.Token, error) {
+func ValidateToken(c *gin.Context, keys *KeySet) (*jwt.Token, error) {
 	// 1. Pegar o header "Authorization"
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
@@ -24,23 +27,31 @@ func ValidateToken(c *gin.Context, jwtSecret string) (*jwt.Token, error) {
 	}
 	tokenString := parts[1]
 
-	// 3. Parse e validação do token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Valida o método de assinatura (esperamos HMAC)
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
-		}
-		// Retorna o segredo
-		return []byte(jwtSecret), nil
-	})
+	// 3. Parse e validação do token (a chave é escolhida pelo kid e pelo algoritmo).
+	// O motivo da recusa vai só para o log: o cliente recebe a mensagem fixa
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
 
 	if err != nil {
-		return nil, fmt.Errorf("token inválido: %v", err)
+		slog.InfoContext(c.Request.Context(), "Token JWT recusado", "erro", err)
+		return nil, ErrTokenInvalido
 	}
 
 	if !token.Valid {
-		return nil, errors.New("token inválido")
+		return nil, ErrTokenInvalido
 	}
 
 	return token, nil
 }
+
+// APIKeyFromRequest retorna a chave de API enviada no header X-API-Key
+// ou como "Authorization: Bearer eco_..." ("" se não houver)
+func APIKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "Bearer" && looksLikeAPIKey(parts[1]) {
+		return parts[1]
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// Município e usuário usados nos testes
+const (
+	testTenantID = "11111111-1111-1111-1111-111111111111"
+	testUserID   = "22222222-2222-2222-2222-222222222222"
+	testEmail    = "ana@prefeitura.gov.br"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newMockDB cria um banco falso que confere as consultas (regex) na ordem esperada
+func newMockDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("consultas esperadas não executadas: %v", err)
+		}
+		db.Close()
+	})
+	return sqlx.NewDb(db, "postgres"), mock
+}
+
+// newTestHandler monta o handler de autenticação sobre o banco falso
+func newTestHandler(t *testing.T, db *sqlx.DB, mfaOpts MFAOptions) *Handler {
+	t.Helper()
+	keys, err := NewKeySet(KeyOptions{Secret: "segredo-de-teste-com-32-caracteres"})
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+	guard := NewLoginGuard(NewAttemptRepository(db), testGuardOptions)
+	return NewHandler(user.NewRepository(db), NewSessionRepository(db), guard, keys, NewMFARepository(db), mfaOpts, 15*time.Minute, 24*time.Hour)
+}
+
+// newTestRouter cria um router com o município de teste já resolvido
+func newTestRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		tenant.SetInContext(c, &tenant.Tenant{ID: testTenantID, Slug: tenant.DefaultSlug})
+		c.Next()
+	})
+	return r
+}
+
+// userRows é a linha de um usuário como o user.Repository a lê
+func userRows(u user.User) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "tenant_id", "email", "password_hash", "role", "ativo", "created_at", "updated_at"}).
+		AddRow(u.ID, u.TenantID, u.Email, u.PasswordHash, u.Role, u.Ativo, u.CreatedAt, u.UpdatedAt)
+}
+
+// captureArg é um argumento do sqlmock que aceita qualquer string e a guarda
+type captureArg struct {
+	value *string
+}
+
+func (a captureArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if ok {
+		*a.value = s
+	}
+	return ok
+}
+
+func TestValidateTokenHidesParseError(t *testing.T) {
+	keys, err := NewKeySet(KeyOptions{Secret: "segredo-de-teste-com-32-caracteres"})
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	// O erro do parse (formato, assinatura, validade) não chega ao cliente
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Request.Header.Set("Authorization", "Bearer nao.e.jwt")
+	if _, err := ValidateToken(c, keys); err != ErrTokenInvalido || err.Error() != "token inválido" {
+		t.Errorf("erro = %v, esperado a mensagem fixa %q", err, ErrTokenInvalido)
+	}
+}
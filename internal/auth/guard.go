@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// GuardOptions configura a proteção contra força bruta no login
+type GuardOptions struct {
+	Janela           time.Duration // Período em que as falhas são contadas
+	TentativasLivres int           // Falhas seguidas sem nenhum atraso
+	AtrasoBase       time.Duration // Espera após a primeira falha além das livres (dobra a cada falha)
+	MaxFalhasConta   int           // Falhas seguidas que bloqueiam a conta
+	MaxFalhasIP      int           // Falhas de um IP (em qualquer conta) que bloqueiam o IP
+	Bloqueio         time.Duration // Duração do bloqueio, contada da última falha
+}
+
+// LoginGuard decide se uma tentativa de login pode prosseguir,
+// com atrasos progressivos e bloqueio temporário por conta e por IP
+type LoginGuard struct {
+	attempts *AttemptRepository
+	opts     GuardOptions
+}
+
+// NewLoginGuard cria uma nova instância da proteção
+func NewLoginGuard(attempts *AttemptRepository, opts GuardOptions) *LoginGuard {
+	return &LoginGuard{attempts: attempts, opts: opts}
+}
+
+// Begin reserva a tentativa antes de a senha ser conferida e retorna o ID da
+// reserva e quanto tempo o cliente ainda precisa esperar (0 = pode tentar agora).
+// Com espera, a tentativa já fica gravada como "bloqueado". O resultado de uma
+// tentativa liberada é gravado depois com Finish.
+func (g *LoginGuard) Begin(ctx context.Context, a LoginAttempt) (int64, time.Duration, error) {
+	now := time.Now()
+	return g.attempts.Reserve(ctx, a, now.Add(-g.opts.Janela), func(conta, porIP failureStats) time.Duration {
+		return g.wait(conta, porIP, now)
+	})
+}
+
+// wait combina a espera imposta pelas falhas da conta e pelas do IP
+func (g *LoginGuard) wait(conta, porIP failureStats, now time.Time) time.Duration {
+	// 1. Falhas seguidas da conta: atraso progressivo e, depois, bloqueio
+	wait := g.accountWait(conta, now)
+
+	// 2. Falhas do IP, em qualquer conta (tentativas de vários e-mails)
+	if porIP.Falhas >= g.opts.MaxFalhasIP && porIP.Ultima.Valid {
+		wait = max(wait, porIP.Ultima.Time.Add(g.opts.Bloqueio).Sub(now))
+	}
+
+	return max(wait, 0)
+}
+
+// accountWait calcula a espera imposta pelas falhas seguidas da conta
+func (g *LoginGuard) accountWait(st failureStats, now time.Time) time.Duration {
+	if !st.Ultima.Valid || st.Falhas <= g.opts.TentativasLivres {
+		return 0
+	}
+
+	// Bloqueio temporário
+	if st.Falhas >= g.opts.MaxFalhasConta {
+		return st.Ultima.Time.Add(g.opts.Bloqueio).Sub(now)
+	}
+
+	// Atraso progressivo: base, 2x base, 4x base... (nunca maior que o bloqueio)
+	delay := g.opts.AtrasoBase
+	for i := g.opts.TentativasLivres + 1; i < st.Falhas && delay < g.opts.Bloqueio; i++ {
+		delay *= 2
+	}
+	return st.Ultima.Time.Add(min(delay, g.opts.Bloqueio)).Sub(now)
+}
+
+// Record grava o resultado de uma tentativa na auditoria: atualiza a reserva
+// feita por Begin (a.ID) ou, sem reserva, insere um novo registro
+func (g *LoginGuard) Record(ctx context.Context, a LoginAttempt) error {
+	if a.ID != 0 {
+		return g.attempts.Finish(ctx, a)
+	}
+	return g.attempts.Record(ctx, a)
+}
+
+// RunRetention apaga periodicamente as tentativas mais antigas que a retenção,
+// até o contexto ser cancelado
+func (g *LoginGuard) RunRetention(ctx context.Context, retencao time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		removidas, err := g.attempts.PurgeBefore(ctx, time.Now().Add(-retencao))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.ErrorContext(ctx, "Erro ao apagar tentativas de login antigas", "erro", err)
+		case removidas > 0:
+			slog.InfoContext(ctx, "Tentativas de login antigas apagadas", "removidas", removidas)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// testGuardOptions é a proteção usada pelo handler dos testes
+var testGuardOptions = GuardOptions{
+	Janela:           15 * time.Minute,
+	TentativasLivres: 3,
+	AtrasoBase:       2 * time.Second,
+	MaxFalhasConta:   10,
+	MaxFalhasIP:      50,
+	Bloqueio:         15 * time.Minute,
+}
+
+func TestGuardWait(t *testing.T) {
+	g := NewLoginGuard(nil, testGuardOptions)
+	now := time.Now()
+	ultima := sql.NullTime{Time: now, Valid: true}
+
+	tests := []struct {
+		name  string
+		conta failureStats
+		porIP failureStats
+		want  time.Duration
+	}{
+		{"sem falhas", failureStats{}, failureStats{}, 0},
+		{"falhas livres", failureStats{Falhas: 3, Ultima: ultima}, failureStats{Falhas: 3, Ultima: ultima}, 0},
+		{"primeira falha com atraso", failureStats{Falhas: 4, Ultima: ultima}, failureStats{}, 2 * time.Second},
+		{"atraso dobra", failureStats{Falhas: 6, Ultima: ultima}, failureStats{}, 8 * time.Second},
+		{"última falha antes do bloqueio", failureStats{Falhas: 9, Ultima: ultima}, failureStats{}, 64 * time.Second},
+		{"conta bloqueada", failureStats{Falhas: 10, Ultima: ultima}, failureStats{}, 15 * time.Minute},
+		{"bloqueio contado da última falha", failureStats{Falhas: 10, Ultima: sql.NullTime{Time: now.Add(-10 * time.Minute), Valid: true}}, failureStats{}, 5 * time.Minute},
+		{"bloqueio já passou", failureStats{Falhas: 10, Ultima: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}}, failureStats{}, 0},
+		{"IP bloqueado", failureStats{}, failureStats{Falhas: 50, Ultima: ultima}, 15 * time.Minute},
+		{"vale a maior espera", failureStats{Falhas: 4, Ultima: ultima}, failureStats{Falhas: 50, Ultima: ultima}, 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.wait(tt.conta, tt.porIP, now); got != tt.want {
+				t.Errorf("wait = %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// expectReserve espera a reserva da tentativa com as falhas informadas da conta e do IP
+func expectReserve(mock sqlmock.Sqlmock, falhasConta, falhasIP int, resultado string) {
+	ultima := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(`pg_advisory_xact_lock\(hashtextextended\('login:conta:'`).
+		WithArgs(testTenantID, testEmail).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`pg_advisory_xact_lock\(hashtextextended\('login:ip:'`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FROM login_attempts\s+WHERE\s+tenant_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"falhas", "ultima"}).AddRow(falhasConta, ultima))
+	mock.ExpectQuery(`FROM login_attempts\s+WHERE\s+ip = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"falhas", "ultima"}).AddRow(falhasIP, ultima))
+	mock.ExpectQuery(`INSERT INTO login_attempts`).
+		WithArgs(testTenantID, testEmail, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), resultado).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+	mock.ExpectCommit()
+}
+
+// postLogin chama POST /api/auth/login
+func postLogin(h *Handler, email, password string) *httptest.ResponseRecorder {
+	r := newTestRouter()
+	r.POST("/api/auth/login", h.Login)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login",
+		strings.NewReader(`{"email": "`+email+`", "password": "`+password+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginBlockedAccountSkipsPasswordCheck(t *testing.T) {
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{})
+
+	// Conta bloqueada: a tentativa é gravada como "bloqueado" e o usuário nem é buscado
+	expectReserve(mock, 10, 10, ResultadoBloqueado)
+
+	w := postLogin(h, testEmail, "senha-qualquer")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, esperado 429 (corpo %s)", w.Code, w.Body)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("resposta sem Retry-After")
+	}
+}
+
+func TestLoginFailureFinishesReservation(t *testing.T) {
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{})
+
+	// A reserva (em andamento) recebe o resultado depois de conferir o usuário;
+	// reserva e busca usam o mesmo email normalizado
+	expectReserve(mock, 2, 2, ResultadoEmAndamento)
+	mock.ExpectQuery(`FROM users WHERE tenant_id = \$1 AND email = \$2`).
+		WithArgs(testTenantID, testEmail).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`UPDATE login_attempts SET resultado = \$2`).
+		WithArgs(int64(42), ResultadoUsuarioInexistente, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := postLogin(h, " Ana@Prefeitura.gov.br ", "senha-qualquer")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, esperado 401 (corpo %s)", w.Code, w.Body)
+	}
+}
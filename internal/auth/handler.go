@@ -2,27 +2,45 @@ package auth
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
 	"github.com/ericoliveiras/ecoponto-api/internal/user"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Handler é a camada que lida com requisições de autenticação
 type Handler struct {
-	userRepo  *user.Repository 
-	jwtSecret string
+	userRepo   *user.Repository
+	sessions   *SessionRepository
+	guard      *LoginGuard
+	keys       *KeySet
+	mfa        *MFARepository
+	mfaOpts    MFAOptions
+	accessTTL  time.Duration // Validade do access token (JWT)
+	refreshTTL time.Duration // Validade de cada refresh token
 }
 
 // NewHandler cria um novo handler de autenticação
-func NewHandler(userRepo *user.Repository, jwtSecret string) *Handler {
+func NewHandler(userRepo *user.Repository, sessions *SessionRepository, guard *LoginGuard, keys *KeySet, mfa *MFARepository, mfaOpts MFAOptions, accessTTL, refreshTTL time.Duration) *Handler {
 	return &Handler{
-		userRepo:  userRepo,
-		jwtSecret: jwtSecret,
+		userRepo:   userRepo,
+		sessions:   sessions,
+		guard:      guard,
+		keys:       keys,
+		mfa:        mfa,
+		mfaOpts:    mfaOpts,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
 	}
 }
 
@@ -32,63 +50,248 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest é a struct para o JSON de refresh e logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenResponse é a resposta do login e do refresh
+type TokenResponse struct {
+	Token        string `json:"token"`         // Access token (JWT), enviado no header Authorization
+	RefreshToken string `json:"refresh_token"` // Usado uma única vez em POST /api/auth/refresh
+	ExpiresIn    int    `json:"expires_in"`    // Segundos até o access token expirar
+}
+
 // Login é o método para o endpoint POST /api/auth/login
 func (h *Handler) Login(c *gin.Context) {
+	ctx := c.Request.Context()
 	var req LoginRequest
 
 	// 1. Valida o JSON de entrada
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// 2. Busca o usuário pelo email
-	u, err := h.userRepo.FindByEmail(c.Request.Context(), req.Email)
+	tenantID := tenant.IDFromContext(c)
+	userAgent := c.Request.UserAgent()
+	attempt := LoginAttempt{
+		TenantID:  tenantID,
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		IP:        c.ClientIP(),
+		UserAgent: &userAgent,
+	}
+
+	// 2. Reserva a tentativa antes de conferir a senha; recusa sem conferir
+	// se a conta ou o IP estiverem em espera/bloqueados
+	var wait time.Duration
+	var err error
+	attempt.ID, wait, err = h.guard.Begin(ctx, attempt)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		apierror.Respond(c, http.StatusTooManyRequests, fmt.Sprintf("Muitas tentativas de login. Tente novamente em %d segundos", seconds))
+		return
+	}
+
+	// 3. Busca o usuário pelo email normalizado (o mesmo da reserva), dentro
+	// do município da requisição
+	u, err := h.userRepo.FindByEmail(ctx, tenantID, attempt.Email)
 	if err != nil {
 		// Se o usuário não existe (ErrNoRows) ou outro erro,
 		// damos uma resposta genérica de "não autorizado"
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Email ou senha inválidos"})
+			attempt.Resultado = ResultadoUsuarioInexistente
+			h.recordAttempt(c, attempt)
+			apierror.Respond(c, http.StatusUnauthorized, "Email ou senha inválidos")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Internal(c, err)
+		return
+	}
+	attempt.UserID = &u.ID
+
+	// 4. Compara a senha enviada com o hash salvo no banco
+	if !CheckPassword(u.PasswordHash, req.Password) {
+		// Senha não bate
+		attempt.Resultado = ResultadoSenhaInvalida
+		h.recordAttempt(c, attempt)
+		apierror.Respond(c, http.StatusUnauthorized, "Email ou senha inválidos")
+		return
+	}
+
+	// Conta desativada por um administrador
+	if !u.Ativo {
+		attempt.Resultado = ResultadoDesativado
+		h.recordAttempt(c, attempt)
+		apierror.Respond(c, http.StatusForbidden, "Usuário desativado")
 		return
 	}
 
-	// 3. Compara a senha enviada com o hash salvo no banco
-	err = bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password))
+	// 5. Senha correta! Se o usuário usa (ou é obrigado a usar) 2FA,
+	// o login só termina em POST /api/auth/login/2fa
+	challenge, err := h.mfaChallenge(ctx, u)
 	if err != nil {
-		// Senha não bate (o erro é 'mismatched hash and password')
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Email ou senha inválidos"})
+		apierror.Internal(c, err)
+		return
+	}
+	if challenge != nil {
+		attempt.Resultado = ResultadoAguardando2FA
+		h.recordAttempt(c, attempt)
+		c.JSON(http.StatusOK, challenge)
 		return
 	}
 
-	// 4. Senha correta! Gerar o token JWT
-	tokenString, err := h.generateToken(u)
+	// 6. Abre a sessão e gera os tokens
+	resp, err := h.startSession(c, u)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao gerar token"})
+		apierror.Internal(c, fmt.Errorf("abrir sessão: %w", err))
 		return
 	}
+	attempt.Resultado = ResultadoSucesso
+	h.recordAttempt(c, attempt)
+
+	// 7. Retorna os tokens para o cliente
+	c.JSON(http.StatusOK, resp)
+}
+
+// recordAttempt grava a tentativa na auditoria; uma falha aqui não impede o login
+func (h *Handler) recordAttempt(c *gin.Context, a LoginAttempt) {
+	if err := h.guard.Record(c.Request.Context(), a); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Erro ao registrar tentativa de login", "erro", err)
+	}
+}
+
+// Refresh é o método para o endpoint POST /api/auth/refresh.
+// Troca o refresh token por um novo par de tokens; o token enviado deixa de valer.
+func (h *Handler) Refresh(c *gin.Context) {
+	ctx := c.Request.Context()
 
-	// 5. Retorna o token para o cliente
-	c.JSON(http.StatusOK, gin.H{
-		"token": tokenString,
+	// 1. Valida o JSON de entrada
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Gera o próximo refresh token e troca pelo atual
+	refreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		apierror.Internal(c, fmt.Errorf("gerar refresh token: %w", err))
+		return
+	}
+	tenantID := tenant.IDFromContext(c)
+	s, err := h.sessions.Rotate(ctx, tenantID, hashToken(req.RefreshToken), refreshHash, time.Now().Add(h.refreshTTL))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrRefreshReutilizado):
+			slog.WarnContext(ctx, "Refresh token reutilizado: sessão revogada", "tenant_id", tenantID)
+			apierror.Respond(c, http.StatusUnauthorized, err.Error())
+		case errors.Is(err, ErrRefreshInvalido):
+			apierror.Respond(c, http.StatusUnauthorized, err.Error())
+		default:
+			apierror.Internal(c, err)
+		}
+		return
+	}
+
+	// 3. Relê o usuário, para o token refletir o cadastro atual
+	u, err := h.userRepo.FindByID(ctx, tenantID, s.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusUnauthorized, ErrRefreshInvalido.Error())
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+	if !u.Ativo {
+		apierror.Respond(c, http.StatusForbidden, "Usuário desativado")
+		return
+	}
+
+	// 4. Gera o novo access token da mesma sessão
+	accessToken, err := h.generateToken(u, s.ID)
+	if err != nil {
+		apierror.Internal(c, fmt.Errorf("gerar access token: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.accessTTL.Seconds()),
 	})
 }
 
+// Logout é o método para o endpoint POST /api/auth/logout.
+// Encerra a sessão do refresh token: ele e os access tokens dela deixam de valer.
+func (h *Handler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := h.sessions.RevokeByToken(c.Request.Context(), tenant.IDFromContext(c), hashToken(req.RefreshToken))
+	if err != nil && err != sql.ErrNoRows {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// Token desconhecido também responde 204: o cliente já está deslogado
+	c.Status(http.StatusNoContent)
+}
+
+// startSession abre uma sessão para o usuário e gera o primeiro par de tokens
+func (h *Handler) startSession(c *gin.Context, u *user.User) (*TokenResponse, error) {
+	refreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent, ip := c.Request.UserAgent(), c.ClientIP()
+	s, err := h.sessions.Create(c.Request.Context(), Session{
+		UserID:    u.ID,
+		TenantID:  u.TenantID,
+		UserAgent: &userAgent,
+		IP:        &ip,
+	}, refreshHash, time.Now().Add(h.refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := h.generateToken(u, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.accessTTL.Seconds()),
+	}, nil
+}
+
 // generateToken é um helper privado para criar o JWT
-func (h *Handler) generateToken(u *user.User) (string, error) {
+func (h *Handler) generateToken(u *user.User, sessionID string) (string, error) {
+	now := time.Now()
+
 	// 1. Define os "Claims" (dados) do token
 	claims := jwt.MapClaims{
-		"sub":   u.ID, // "Subject" (assunto) - o ID do usuário
-		"email": u.Email,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(), // Expira em 24h
-		"iat":   time.Now().Unix(),                     // "Issued At" (criado em)
+		"sub":    u.ID, // "Subject" (assunto) - o ID do usuário
+		"email":  u.Email,
+		"tenant": u.TenantID,                  // Município ao qual o token dá acesso
+		"sid":    sessionID,                   // Sessão: revogada no logout
+		"role":   u.Role,                      // Papel: define as permissões nas rotas de admin
+		"exp":    now.Add(h.accessTTL).Unix(), // Vida curta; renovado com o refresh token
+		"iat":    now.Unix(),                  // "Issued At" (criado em)
 	}
 
-	// 2. Cria o token com o método de assinatura HMAC
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// 3. Assina o token com o nosso segredo
-	return token.SignedString([]byte(h.jwtSecret))
+	// 2. Assina com a chave ativa (RS256/EdDSA com kid, ou HS256 no modo legado)
+	return h.keys.Sign(claims)
 }
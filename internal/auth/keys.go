@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyOptions configura as chaves usadas para assinar e validar os JWTs
+type KeyOptions struct {
+	Secret         string   // Segredo HMAC (HS256): usado quando não há chave privada
+	SigningKeyFile string   // PEM com a chave privada RSA (RS256) ou Ed25519 (EdDSA)
+	VerifyKeyFiles []string // PEMs com chaves públicas ainda aceitas (rotação)
+
+	// AcceptHS256 mantém os tokens HS256 (assinados com Secret) válidos mesmo com a
+	// chave privada configurada, durante a migração. Sem ele, o Secret é ignorado.
+	AcceptHS256 bool
+}
+
+// verifyKey é uma chave pública aceita na validação
+type verifyKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+}
+
+// KeySet assina os tokens com a chave ativa e valida com qualquer chave publicada.
+// Sem chave privada configurada, usa HS256 com o segredo (modo legado).
+type KeySet struct {
+	secret []byte
+
+	signingKID    string
+	signingMethod jwt.SigningMethod
+	signingKey    crypto.PrivateKey
+
+	verify map[string]verifyKey
+}
+
+// NewKeySet carrega as chaves dos arquivos PEM configurados
+func NewKeySet(opts KeyOptions) (*KeySet, error) {
+	ks := &KeySet{verify: make(map[string]verifyKey)}
+	if opts.AcceptHS256 && opts.Secret == "" {
+		return nil, errors.New("JWT_ACCEPT_HS256 exige o JWT_SECRET")
+	}
+	// Com chave privada, o segredo só vale se os tokens HS256 forem aceitos explicitamente:
+	// um segredo esquecido na configuração não continua abrindo a API
+	if opts.Secret != "" && (opts.SigningKeyFile == "" || opts.AcceptHS256) {
+		ks.secret = []byte(opts.Secret)
+	}
+
+	// 1. Chave de assinatura (opcional)
+	if opts.SigningKeyFile != "" {
+		priv, err := loadPEM(opts.SigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("%s não contém uma chave privada", opts.SigningKeyFile)
+		}
+		vk, err := newVerifyKey(signer.Public())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opts.SigningKeyFile, err)
+		}
+		ks.signingKID, ks.signingMethod, ks.signingKey = vk.kid, vk.method, priv
+		ks.verify[vk.kid] = vk
+	} else if ks.secret == nil {
+		return nil, errors.New("configure JWT_SIGNING_KEY_FILE ou JWT_SECRET")
+	}
+
+	// 2. Chaves antigas ainda aceitas durante a rotação
+	for _, file := range opts.VerifyKeyFiles {
+		key, err := loadPEM(file)
+		if err != nil {
+			return nil, err
+		}
+		if signer, ok := key.(crypto.Signer); ok {
+			key = signer.Public()
+		}
+		vk, err := newVerifyKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		ks.verify[vk.kid] = vk
+	}
+
+	return ks, nil
+}
+
+// Sign assina os claims com a chave ativa, informando o kid no header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+	}
+	token := jwt.NewWithClaims(ks.signingMethod, claims)
+	token.Header["kid"] = ks.signingKID
+	return token.SignedString(ks.signingKey)
+}
+
+// Keyfunc escolhe a chave de validação pelo kid e pelo algoritmo do token.
+// Tokens HMAC só são aceitos no modo HS256 ou, com chave privada, com AcceptHS256.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if ks.secret == nil {
+			return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+		}
+		return ks.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	vk, ok := ks.verify[kid]
+	if !ok {
+		return nil, fmt.Errorf("chave de assinatura desconhecida: %q", kid)
+	}
+	if token.Method.Alg() != vk.method.Alg() {
+		return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+	}
+	return vk.key, nil
+}
+
+// JWK é uma chave pública no formato JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA
+	E   string `json:"e,omitempty"`   // RSA
+	Crv string `json:"crv,omitempty"` // OKP
+	X   string `json:"x,omitempty"`   // OKP
+}
+
+// JWKS é o documento publicado em /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS retorna as chaves públicas de validação (vazio no modo HS256)
+func (ks *KeySet) JWKS() JWKS {
+	// A chave ativa vem primeiro; as demais em ordem de kid (saída estável)
+	kids := make([]string, 0, len(ks.verify))
+	for kid := range ks.verify {
+		kids = append(kids, kid)
+	}
+	sort.Slice(kids, func(i, j int) bool {
+		if (kids[i] == ks.signingKID) != (kids[j] == ks.signingKID) {
+			return kids[i] == ks.signingKID
+		}
+		return kids[i] < kids[j]
+	})
+
+	doc := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		vk := ks.verify[kid]
+		jwk := publicJWK(vk.key)
+		jwk.Kid, jwk.Use, jwk.Alg = vk.kid, "sig", vk.method.Alg()
+		doc.Keys = append(doc.Keys, jwk)
+	}
+	return doc
+}
+
+// newVerifyKey identifica o algoritmo da chave pública e calcula o kid
+// (thumbprint RFC 7638: a mesma chave gera sempre o mesmo kid, em qualquer instância)
+func newVerifyKey(pub crypto.PublicKey) (verifyKey, error) {
+	var method jwt.SigningMethod
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return verifyKey{}, fmt.Errorf("tipo de chave não suportado: %T (use RSA ou Ed25519)", pub)
+	}
+	return verifyKey{kid: thumbprint(pub), method: method, key: pub}, nil
+}
+
+// publicJWK converte a chave pública nos campos do JWK
+func publicJWK(pub crypto.PublicKey) JWK {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(k)}
+	}
+	return JWK{}
+}
+
+// thumbprint calcula o JWK thumbprint (RFC 7638), usado como kid
+func thumbprint(pub crypto.PublicKey) string {
+	jwk := publicJWK(pub)
+	// Membros obrigatórios, em ordem lexicográfica, sem espaços
+	var members any
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	b, _ := json.Marshal(members)
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// loadPEM lê uma chave PEM: privada (PKCS#1, PKCS#8) ou pública (PKIX, PKCS#1)
+func loadPEM(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler a chave %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s não é um arquivo PEM", path)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: bloco PEM não suportado: %q", path, block.Type)
+	}
+}
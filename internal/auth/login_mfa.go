@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAChallengeResponse é a resposta do login quando falta o segundo fator
+type MFAChallengeResponse struct {
+	MFARequired         bool   `json:"mfa_required"`
+	MFAToken            string `json:"mfa_token"`            // Enviado em POST /api/auth/login/2fa
+	ExpiresIn           int    `json:"expires_in"`           // Segundos até o mfa_token expirar
+	CadastroObrigatorio bool   `json:"cadastro_obrigatorio"` // O papel exige 2FA e o usuário ainda não cadastrou
+}
+
+// LoginMFARequest é o corpo de POST /api/auth/login/2fa (código do aplicativo ou de recuperação)
+type LoginMFARequest struct {
+	MFAToken          string `json:"mfa_token" binding:"required"`
+	Codigo            string `json:"codigo"`
+	CodigoRecuperacao string `json:"codigo_recuperacao"`
+}
+
+// LoginMFAEnrollRequest é o corpo de POST /api/auth/login/2fa/cadastro
+type LoginMFAEnrollRequest struct {
+	MFAToken       string `json:"mfa_token" binding:"required"`
+	CodigoCadastro string `json:"codigo_cadastro" binding:"required"` // Emitido por um administrador
+}
+
+// LoginMFAResponse são os tokens do login, mais os códigos de recuperação
+// quando o 2FA acabou de ser cadastrado
+type LoginMFAResponse struct {
+	TokenResponse
+	CodigosRecuperacao []string `json:"codigos_recuperacao,omitempty"`
+}
+
+// mfaChallenge cria um desafio se o usuário tem 2FA ativo ou se o papel dele o exige
+// (nil = o login pode terminar só com a senha)
+func (h *Handler) mfaChallenge(ctx context.Context, u *user.User) (*MFAChallengeResponse, error) {
+	t, err := h.mfa.FindTOTP(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Ativo() && !h.mfaOpts.Required(u.Role) {
+		return nil, nil
+	}
+
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := h.mfa.CreateChallenge(ctx, u.ID, u.TenantID, hash, time.Now().Add(h.mfaOpts.ChallengeTTL)); err != nil {
+		return nil, err
+	}
+
+	return &MFAChallengeResponse{
+		MFARequired:         true,
+		MFAToken:            token,
+		ExpiresIn:           int(h.mfaOpts.ChallengeTTL.Seconds()),
+		CadastroObrigatorio: !t.Ativo(),
+	}, nil
+}
+
+// challengeUser gasta uma tentativa do desafio e busca o usuário dele,
+// respondendo o erro ao cliente quando não houver
+func (h *Handler) challengeUser(c *gin.Context, mfaToken string) (*user.User, bool) {
+	ctx := c.Request.Context()
+	tenantID := tenant.IDFromContext(c)
+
+	userID, err := h.mfa.UseChallengeAttempt(ctx, tenantID, hashToken(mfaToken))
+	if err != nil {
+		if errors.Is(err, ErrDesafioInvalido) {
+			apierror.Respond(c, http.StatusUnauthorized, err.Error())
+			return nil, false
+		}
+		apierror.Internal(c, err)
+		return nil, false
+	}
+
+	u, err := h.userRepo.FindByID(ctx, tenantID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusUnauthorized, ErrDesafioInvalido.Error())
+			return nil, false
+		}
+		apierror.Internal(c, err)
+		return nil, false
+	}
+	if !u.Ativo {
+		apierror.Respond(c, http.StatusForbidden, "Usuário desativado")
+		return nil, false
+	}
+	return u, true
+}
+
+// LoginMFAEnroll é o método para o endpoint POST /api/auth/login/2fa/cadastro.
+// Usado quando o papel exige 2FA e o usuário ainda não cadastrou: com o código de
+// cadastro emitido por um administrador (a senha sozinha não basta para vincular um
+// aplicativo à conta), devolve o segredo a ser lido pelo aplicativo; o primeiro
+// código é enviado em POST /api/auth/login/2fa.
+func (h *Handler) LoginMFAEnroll(c *gin.Context) {
+	// 1. Valida o JSON de entrada
+	var req LoginMFAEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Busca o usuário do desafio (gasta uma tentativa dele)
+	u, ok := h.challengeUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	// 3. Consome o código de cadastro e gera o segredo pendente
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	err = h.mfa.SavePendingWithCode(c.Request.Context(), u.ID, hashToken(strings.TrimSpace(req.CodigoCadastro)), secret)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCodigoCadastroInvalido):
+			apierror.Respond(c, http.StatusUnauthorized, err.Error())
+			return
+		case errors.Is(err, ErrTOTPJaAtivo):
+			apierror.Respond(c, http.StatusConflict, err.Error())
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, TOTPSetupResponse{Secret: secret, URI: totpURI(h.mfaOpts.Issuer, u.Email, secret)})
+}
+
+// LoginMFA é o método para o endpoint POST /api/auth/login/2fa.
+// Troca o mfa_token e o código (do aplicativo ou de recuperação) pelos tokens.
+// Se o cadastro do 2FA estava pendente, o código o confirma e a resposta traz
+// os códigos de recuperação.
+func (h *Handler) LoginMFA(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. Valida o JSON de entrada
+	var req LoginMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (req.Codigo == "") == (req.CodigoRecuperacao == "") {
+		apierror.Respond(c, http.StatusBadRequest, "Informe 'codigo' ou 'codigo_recuperacao'")
+		return
+	}
+
+	// 2. Busca o usuário do desafio, gastando uma tentativa antes de conferir o código
+	u, ok := h.challengeUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+	userAgent := c.Request.UserAgent()
+	attempt := LoginAttempt{
+		TenantID:  u.TenantID,
+		Email:     strings.ToLower(u.Email),
+		UserID:    &u.ID,
+		IP:        c.ClientIP(),
+		UserAgent: &userAgent,
+	}
+
+	// 3. Os códigos errados contam para a mesma proteção contra força bruta do login
+	var wait time.Duration
+	var err error
+	attempt.ID, wait, err = h.guard.Begin(ctx, attempt)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		apierror.Respond(c, http.StatusTooManyRequests, fmt.Sprintf("Muitas tentativas de login. Tente novamente em %d segundos", seconds))
+		return
+	}
+
+	// 4. Confere o segundo fator (ou confirma o cadastro pendente)
+	t, err := h.mfa.FindTOTP(ctx, u.ID)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	var codigos []string
+	switch {
+	case t == nil:
+		apierror.Respond(c, http.StatusBadRequest, "Cadastre o 2FA em POST /api/auth/login/2fa/cadastro")
+		return
+	case !t.Ativo():
+		if req.Codigo == "" {
+			apierror.Respond(c, http.StatusBadRequest, "Informe o 'codigo' do aplicativo para confirmar o cadastro")
+			return
+		}
+		codigos, err = confirmTOTPEnrollment(ctx, h.mfa, t, req.Codigo)
+		ok = err == nil
+		if errors.Is(err, ErrCodigoInvalido) {
+			err = nil
+		}
+	default:
+		ok, err = verifySecondFactor(ctx, h.mfa, t, req.Codigo, req.CodigoRecuperacao)
+	}
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if !ok {
+		attempt.Resultado = ResultadoCodigo2FAInvalido
+		h.recordAttempt(c, attempt)
+		apierror.Respond(c, http.StatusUnauthorized, ErrCodigoInvalido.Error())
+		return
+	}
+
+	// 5. O desafio vale uma única vez
+	consumed, err := h.mfa.ConsumeChallenge(ctx, hashToken(req.MFAToken))
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if !consumed {
+		apierror.Respond(c, http.StatusUnauthorized, ErrDesafioInvalido.Error())
+		return
+	}
+
+	// 6. Abre a sessão e gera os tokens
+	resp, err := h.startSession(c, u)
+	if err != nil {
+		apierror.Internal(c, fmt.Errorf("abrir sessão: %w", err))
+		return
+	}
+	attempt.Resultado = ResultadoSucesso
+	h.recordAttempt(c, attempt)
+
+	c.JSON(http.StatusOK, LoginMFAResponse{TokenResponse: *resp, CodigosRecuperacao: codigos})
+}
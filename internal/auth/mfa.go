@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxTentativasDesafio é quantos códigos errados um desafio de login aceita antes de ser descartado
+const maxTentativasDesafio = 5
+
+var (
+	// ErrDesafioInvalido é retornado quando o mfa_token não existe, expirou,
+	// já foi usado ou esgotou as tentativas
+	ErrDesafioInvalido = errors.New("mfa_token inválido ou expirado: faça login novamente")
+
+	// ErrTOTPJaAtivo é retornado ao iniciar um cadastro quando o 2FA já está ativo
+	ErrTOTPJaAtivo = errors.New("autenticação em dois fatores já está ativa")
+
+	// ErrCodigoCadastroInvalido é retornado quando o código de cadastro do 2FA
+	// não confere, expirou ou já foi usado
+	ErrCodigoCadastroInvalido = errors.New("codigo_cadastro inválido ou expirado: peça um novo a um administrador")
+)
+
+// MFAOptions configura a autenticação em dois fatores
+type MFAOptions struct {
+	Issuer        string        // Nome exibido no aplicativo autenticador
+	RequiredRoles []string      // Papéis que só entram com 2FA
+	ChallengeTTL  time.Duration // Validade do mfa_token entre a senha e o código
+	EnrollmentTTL time.Duration // Validade do código de cadastro emitido por um administrador
+}
+
+// Required indica se o papel é obrigado a usar 2FA
+func (o MFAOptions) Required(role string) bool {
+	return slices.Contains(o.RequiredRoles, role)
+}
+
+// TOTP é o segundo fator cadastrado por um usuário
+type TOTP struct {
+	UserID      string        `db:"user_id"`
+	Secret      string        `db:"secret"`
+	ConfirmedAt *time.Time    `db:"confirmed_at"`
+	UltimoPasso sql.NullInt64 `db:"ultimo_passo"`
+}
+
+// Ativo indica se o cadastro foi confirmado com um código
+func (t *TOTP) Ativo() bool {
+	return t != nil && t.ConfirmedAt != nil
+}
+
+// MFARepository gerencia os segredos TOTP, os códigos de recuperação
+// e os desafios de login
+type MFARepository struct {
+	db *sqlx.DB
+}
+
+// NewMFARepository cria uma nova instância do repositório
+func NewMFARepository(db *sqlx.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// FindTOTP busca o segundo fator do usuário (nil se ele nunca iniciou o cadastro)
+func (r *MFARepository) FindTOTP(ctx context.Context, userID string) (*TOTP, error) {
+	var t TOTP
+	err := r.db.GetContext(ctx, &t, `
+		SELECT user_id, secret, confirmed_at, ultimo_passo
+		FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SavePending grava um novo segredo ainda não confirmado, substituindo um cadastro
+// pendente anterior. Retorna ErrTOTPJaAtivo se o 2FA já estiver ativo.
+func (r *MFARepository) SavePending(ctx context.Context, userID, secret string) error {
+	return savePending(ctx, r.db, userID, secret)
+}
+
+// CreateEnrollmentCode grava o código de cadastro do 2FA do usuário,
+// substituindo um código anterior ainda não usado (createdBy vazio = sem autor)
+func (r *MFARepository) CreateEnrollmentCode(ctx context.Context, userID, codeHash, createdBy string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO mfa_enrollment_codes (user_id, code_hash, created_by, expires_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4)
+		ON CONFLICT (user_id) DO UPDATE
+			SET code_hash = EXCLUDED.code_hash, created_by = EXCLUDED.created_by,
+				expires_at = EXCLUDED.expires_at, created_at = NOW()`, userID, codeHash, createdBy, expiresAt)
+	return err
+}
+
+// SavePendingWithCode consome o código de cadastro e grava o segredo pendente na
+// mesma transação. Retorna ErrCodigoCadastroInvalido se o código não conferir ou
+// tiver expirado, e ErrTOTPJaAtivo se o 2FA já estiver ativo.
+func (r *MFARepository) SavePendingWithCode(ctx context.Context, userID, codeHash, secret string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 1. O código vale uma única vez
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM mfa_enrollment_codes
+		WHERE user_id = $1 AND code_hash = $2 AND expires_at > NOW()`, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCodigoCadastroInvalido
+	}
+
+	// 2. Grava o segredo pendente
+	if err := savePending(ctx, tx, userID, secret); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Confirm ativa o cadastro pendente e grava os códigos de recuperação.
+// Retorna false se o cadastro já tinha sido confirmado (pedido repetido).
+func (r *MFARepository) Confirm(ctx context.Context, userID string, step int64, codeHashes []string) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_totp SET confirmed_at = NOW(), ultimo_passo = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// UseStep registra o passo do código aceito. Retorna false se ele (ou um
+// posterior) já tinha sido usado: o mesmo código não vale duas vezes.
+func (r *MFARepository) UseStep(ctx context.Context, userID string, step int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_totp SET ultimo_passo = $2
+		WHERE
+			user_id = $1
+			AND confirmed_at IS NOT NULL
+			AND (ultimo_passo IS NULL OR ultimo_passo < $2)`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// UseRecoveryCode consome um código de recuperação. Retorna false se ele não
+// existir ou já tiver sido usado.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RemainingRecoveryCodes conta os códigos de recuperação ainda não usados
+func (r *MFARepository) RemainingRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := r.db.GetContext(ctx, &n, `
+		SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID)
+	return n, err
+}
+
+// ReplaceRecoveryCodes descarta os códigos de recuperação do usuário e grava os novos
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID string, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// savePending é o helper usado por SavePending e SavePendingWithCode
+func savePending(ctx context.Context, e sqlx.ExecerContext, userID, secret string) error {
+	res, err := e.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+			SET secret = EXCLUDED.secret, ultimo_passo = NULL, created_at = NOW()
+			WHERE user_totp.confirmed_at IS NULL`, userID, secret)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTOTPJaAtivo
+	}
+	return nil
+}
+
+// replaceRecoveryCodes é o helper usado dentro das transações
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, h)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Disable remove o segundo fator e os códigos de recuperação do usuário
+func (r *MFARepository) Disable(ctx context.Context, userID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateChallenge grava um desafio de login para o usuário
+func (r *MFARepository) CreateChallenge(ctx context.Context, userID, tenantID, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO mfa_challenges (user_id, tenant_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)`, userID, tenantID, tokenHash, expiresAt)
+	return err
+}
+
+// UseChallengeAttempt gasta uma das tentativas de um desafio ainda válido no município
+// e retorna o usuário dele, ou ErrDesafioInvalido. A tentativa é gasta antes de o
+// código ser conferido, numa única instrução: requisições paralelas com o mesmo
+// mfa_token não conferem mais códigos que maxTentativasDesafio.
+func (r *MFARepository) UseChallengeAttempt(ctx context.Context, tenantID, tokenHash string) (string, error) {
+	var userID string
+	err := r.db.GetContext(ctx, &userID, `
+		UPDATE mfa_challenges SET tentativas = tentativas + 1
+		WHERE
+			token_hash = $1
+			AND tenant_id = $2
+			AND used_at IS NULL
+			AND expires_at > NOW()
+			AND tentativas < $3
+		RETURNING user_id`, tokenHash, tenantID, maxTentativasDesafio)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrDesafioInvalido
+		}
+		return "", err
+	}
+	return userID, nil
+}
+
+// ConsumeChallenge marca o desafio como usado. Retorna false se outra
+// requisição já o tinha consumido.
+func (r *MFARepository) ConsumeChallenge(ctx context.Context, tokenHash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE mfa_challenges SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL`, tokenHash)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
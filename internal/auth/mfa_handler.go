@@ -0,0 +1,406 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrCodigoInvalido é retornado quando o código TOTP ou de recuperação não confere
+	ErrCodigoInvalido = errors.New("código de verificação inválido")
+
+	// ErrTOTPNaoIniciado é retornado ao confirmar um cadastro que não foi iniciado
+	ErrTOTPNaoIniciado = errors.New("cadastro do 2FA não iniciado")
+)
+
+// TOTPSetupResponse é o segredo de um cadastro de 2FA iniciado
+type TOTPSetupResponse struct {
+	Secret string `json:"secret"`      // Para digitar no aplicativo, se não der para ler o QR code
+	URI    string `json:"otpauth_uri"` // Conteúdo do QR code
+}
+
+// MFAStatusResponse é a resposta de GET /api/auth/2fa
+type MFAStatusResponse struct {
+	Ativo                       bool `json:"ativo"`
+	Obrigatorio                 bool `json:"obrigatorio"` // O papel do usuário exige 2FA
+	CodigosRecuperacaoRestantes int  `json:"codigos_recuperacao_restantes"`
+}
+
+// MFACodeRequest é o corpo das rotas que pedem um código do aplicativo
+type MFACodeRequest struct {
+	Codigo string `json:"codigo" binding:"required"`
+}
+
+// EnrollmentCodeResponse é a resposta de POST /api/usuarios/:id/2fa/cadastro
+type EnrollmentCodeResponse struct {
+	CodigoCadastro string `json:"codigo_cadastro"` // Entregue à pessoa fora da API; mostrado só desta vez
+	ExpiresIn      int    `json:"expires_in"`      // Segundos até o código expirar
+}
+
+// DisableMFARequest é o corpo de POST /api/auth/2fa/desativar
+type DisableMFARequest struct {
+	Senha             string `json:"senha" binding:"required"`
+	Codigo            string `json:"codigo"`
+	CodigoRecuperacao string `json:"codigo_recuperacao"`
+}
+
+// startTOTPEnrollment gera um novo segredo pendente para o usuário
+func startTOTPEnrollment(ctx context.Context, repo *MFARepository, opts MFAOptions, u *user.User) (*TOTPSetupResponse, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.SavePending(ctx, u.ID, secret); err != nil {
+		return nil, err
+	}
+	return &TOTPSetupResponse{Secret: secret, URI: totpURI(opts.Issuer, u.Email, secret)}, nil
+}
+
+// confirmTOTPEnrollment confere o primeiro código contra o segredo pendente e ativa o 2FA.
+// Retorna os códigos de recuperação, que só são mostrados esta vez.
+func confirmTOTPEnrollment(ctx context.Context, repo *MFARepository, t *TOTP, code string) ([]string, error) {
+	step, ok := verifyTOTP(t.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrCodigoInvalido
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	confirmed, err := repo.Confirm(ctx, t.UserID, step, hashes)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, ErrTOTPJaAtivo
+	}
+	return codes, nil
+}
+
+// verifySecondFactor confere o código do aplicativo (uma única vez por passo)
+// ou consome um código de recuperação
+func verifySecondFactor(ctx context.Context, repo *MFARepository, t *TOTP, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		step, ok := verifyTOTP(t.Secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return repo.UseStep(ctx, t.UserID, step)
+	}
+	if recoveryCode != "" {
+		return repo.UseRecoveryCode(ctx, t.UserID, hashRecoveryCode(recoveryCode))
+	}
+	return false, nil
+}
+
+// MFAHandler gerencia o cadastro da autenticação em dois fatores pelo próprio usuário
+// e o reset feito por um administrador
+type MFAHandler struct {
+	userRepo *user.Repository
+	mfa      *MFARepository
+	sessions *SessionRepository
+	opts     MFAOptions
+}
+
+// NewMFAHandler cria uma nova instância do handler
+func NewMFAHandler(userRepo *user.Repository, mfa *MFARepository, sessions *SessionRepository, opts MFAOptions) *MFAHandler {
+	return &MFAHandler{userRepo: userRepo, mfa: mfa, sessions: sessions, opts: opts}
+}
+
+// currentUser busca o usuário autenticado, respondendo o erro ao cliente quando não houver
+func (h *MFAHandler) currentUser(c *gin.Context) (*user.User, bool) {
+	p := PrincipalFromContext(c)
+	u, err := h.userRepo.FindByID(c.Request.Context(), p.TenantID, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return nil, false
+		}
+		apierror.Internal(c, err)
+		return nil, false
+	}
+	return u, true
+}
+
+// GetStatus é o método para o endpoint GET /api/auth/2fa
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+	u, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.mfa.FindTOTP(ctx, u.ID)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	resp := MFAStatusResponse{Ativo: t.Ativo(), Obrigatorio: h.opts.Required(u.Role)}
+	if resp.Ativo {
+		resp.CodigosRecuperacaoRestantes, err = h.mfa.RemainingRecoveryCodes(ctx, u.ID)
+		if err != nil {
+			apierror.Internal(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// StartEnrollment é o método para o endpoint POST /api/auth/2fa.
+// Gera o segredo; o 2FA só passa a valer depois de POST /api/auth/2fa/confirmar.
+func (h *MFAHandler) StartEnrollment(c *gin.Context) {
+	u, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	setup, err := startTOTPEnrollment(c.Request.Context(), h.mfa, h.opts, u)
+	if err != nil {
+		if errors.Is(err, ErrTOTPJaAtivo) {
+			apierror.Respond(c, http.StatusConflict, err.Error())
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// ConfirmEnrollment é o método para o endpoint POST /api/auth/2fa/confirmar.
+// Ativa o 2FA com o primeiro código do aplicativo e devolve os códigos de recuperação.
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	ctx := c.Request.Context()
+	p := PrincipalFromContext(c)
+
+	// 1. Valida o JSON de entrada
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Busca o cadastro pendente
+	t, err := h.mfa.FindTOTP(ctx, p.UserID)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if t == nil {
+		apierror.Respond(c, http.StatusBadRequest, ErrTOTPNaoIniciado.Error())
+		return
+	}
+
+	// 3. Confere o código e ativa
+	codigos, err := confirmTOTPEnrollment(ctx, h.mfa, t, req.Codigo)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCodigoInvalido):
+			apierror.Respond(c, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrTOTPJaAtivo):
+			apierror.Respond(c, http.StatusConflict, err.Error())
+		default:
+			apierror.Internal(c, err)
+		}
+		return
+	}
+
+	// 4. Sessões abertas só com a senha deixam de valer (mantém o dispositivo atual)
+	if err := h.sessions.RevokeOthersForUser(ctx, p.UserID, p.SessionID, MotivoMFA); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"codigos_recuperacao": codigos})
+}
+
+// RegenerateRecoveryCodes é o método para o endpoint POST /api/auth/2fa/codigos-recuperacao.
+// Descarta os códigos antigos e gera novos; exige um código do aplicativo.
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	ctx := c.Request.Context()
+	p := PrincipalFromContext(c)
+
+	// 1. Valida o JSON de entrada
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Confere o código
+	t, err := h.mfa.FindTOTP(ctx, p.UserID)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if !t.Ativo() {
+		apierror.Respond(c, http.StatusBadRequest, "Autenticação em dois fatores não está ativa")
+		return
+	}
+	ok, err := verifySecondFactor(ctx, h.mfa, t, req.Codigo, "")
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, ErrCodigoInvalido.Error())
+		return
+	}
+
+	// 3. Gera os novos códigos
+	codigos, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if err := h.mfa.ReplaceRecoveryCodes(ctx, p.UserID, hashes); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"codigos_recuperacao": codigos})
+}
+
+// Disable é o método para o endpoint POST /api/auth/2fa/desativar.
+// Exige a senha e um código (do aplicativo ou de recuperação); recusado se o papel exige 2FA.
+func (h *MFAHandler) Disable(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. Valida o JSON de entrada
+	var req DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Confere a política e a senha
+	u, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if h.opts.Required(u.Role) {
+		apierror.Respond(c, http.StatusForbidden, "O seu papel exige autenticação em dois fatores")
+		return
+	}
+	if !CheckPassword(u.PasswordHash, req.Senha) {
+		apierror.Respond(c, http.StatusBadRequest, "Senha incorreta")
+		return
+	}
+
+	// 3. Confere o segundo fator
+	t, err := h.mfa.FindTOTP(ctx, u.ID)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if !t.Ativo() {
+		apierror.Respond(c, http.StatusBadRequest, "Autenticação em dois fatores não está ativa")
+		return
+	}
+	ok, err = verifySecondFactor(ctx, h.mfa, t, req.Codigo, req.CodigoRecuperacao)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, ErrCodigoInvalido.Error())
+		return
+	}
+
+	// 4. Remove o segredo e os códigos
+	if err := h.mfa.Disable(ctx, u.ID); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetUserMFA é o método para o endpoint DELETE /api/usuarios/:id/2fa.
+// Um administrador remove o 2FA de quem perdeu o celular e os códigos de recuperação;
+// se o papel exigir, o cadastro é pedido de novo no próximo login (com um novo
+// código de cadastro, emitido em IssueEnrollmentCode).
+func (h *MFAHandler) ResetUserMFA(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. Busca o usuário no município
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	u, err := h.userRepo.FindByID(ctx, tenant.IDFromContext(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 2. Remove o segundo fator e encerra as sessões dele
+	if err := h.mfa.Disable(ctx, u.ID); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if err := h.sessions.RevokeAllForUser(ctx, u.ID, MotivoMFA); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// IssueEnrollmentCode é o método para o endpoint POST /api/usuarios/:id/2fa/cadastro.
+// Um administrador emite o código de uso único que a pessoa informa no primeiro
+// cadastro do 2FA pelo login; um novo pedido invalida o código anterior.
+func (h *MFAHandler) IssueEnrollmentCode(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. Busca o usuário no município
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	u, err := h.userRepo.FindByID(ctx, tenant.IDFromContext(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 2. Com o 2FA já ativo não há cadastro a fazer (para trocar o aparelho, use o reset)
+	t, err := h.mfa.FindTOTP(ctx, u.ID)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if t.Ativo() {
+		apierror.Respond(c, http.StatusConflict, ErrTOTPJaAtivo.Error())
+		return
+	}
+
+	// 3. Gera e grava o código (só o hash)
+	code, hash, err := newOpaqueToken()
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if err := h.mfa.CreateEnrollmentCode(ctx, u.ID, hash, PrincipalFromContext(c).UserID, time.Now().Add(h.opts.EnrollmentTTL)); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, EnrollmentCodeResponse{CodigoCadastro: code, ExpiresIn: int(h.opts.EnrollmentTTL.Seconds())})
+}
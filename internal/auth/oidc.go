@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/database"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// oidcStateTTL é o tempo que o usuário tem para concluir o login no provedor
+const oidcStateTTL = 10 * time.Minute
+
+// oidcStateCookie guarda o state no navegador que iniciou o login: o callback só
+// é aceito no mesmo navegador (um link de callback enviado a outra pessoa não
+// a loga na conta de quem o gerou)
+const oidcStateCookie = "ecoponto_oidc_state"
+
+// OIDCOptions configura o login pelo provedor de identidade (OpenID Connect)
+type OIDCOptions struct {
+	Issuer       string // URL do provedor (a descoberta lê <issuer>/.well-known/openid-configuration)
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // URL pública do nosso callback (/api/auth/oidc/callback)
+	Scopes       []string // Além de "openid"
+	SuccessURL   string   // Página do frontend que recebe os tokens no fragmento (#token=...)
+}
+
+// OIDCHandler faz o login authorization code + PKCE no provedor da prefeitura
+// e, no callback, liga a identidade externa a um usuário já cadastrado e emite os nossos tokens
+type OIDCHandler struct {
+	auth  *Handler
+	store *OIDCStore
+	opts  OIDCOptions
+
+	// Cookie do state: restrito ao caminho do callback e, com HTTPS, só enviado em HTTPS
+	cookiePath   string
+	cookieSecure bool
+
+	// A descoberta é feita na primeira requisição (e repetida se falhar),
+	// para a API subir mesmo com o provedor fora do ar
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDCHandler cria uma nova instância do handler
+func NewOIDCHandler(authHandler *Handler, store *OIDCStore, opts OIDCOptions) *OIDCHandler {
+	h := &OIDCHandler{auth: authHandler, store: store, opts: opts, cookiePath: "/"}
+	if u, err := url.Parse(opts.RedirectURL); err == nil {
+		if u.Path != "" {
+			h.cookiePath = u.Path
+		}
+		h.cookieSecure = u.Scheme == "https"
+	}
+	return h
+}
+
+// setStateCookie grava (ou, com maxAge negativo, apaga) o cookie do state
+func (h *OIDCHandler) setStateCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     h.cookiePath,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.cookieSecure,
+		SameSite: http.SameSiteLaxMode, // Enviado na volta do provedor (navegação GET de outro site)
+	})
+}
+
+// idTokenClaims são os claims do ID token usados no mapeamento para o usuário
+type idTokenClaims struct {
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+}
+
+// discover carrega a configuração do provedor (uma única vez, se der certo)
+func (h *OIDCHandler) discover(ctx context.Context) (*oauth2.Config, *oidc.IDTokenVerifier, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.oauth != nil {
+		return h.oauth, h.verifier, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, h.opts.Issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro na descoberta OIDC: %w", err)
+	}
+
+	h.oauth = &oauth2.Config{
+		ClientID:     h.opts.ClientID,
+		ClientSecret: h.opts.ClientSecret,
+		RedirectURL:  h.opts.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       append([]string{oidc.ScopeOpenID}, h.opts.Scopes...),
+	}
+	h.verifier = provider.Verifier(&oidc.Config{ClientID: h.opts.ClientID})
+	return h.oauth, h.verifier, nil
+}
+
+// Login é o método para o endpoint GET /api/auth/oidc/login.
+// Redireciona o navegador para o provedor de identidade.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. Carrega a configuração do provedor
+	oauth, _, err := h.discover(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao carregar o provedor OIDC", "erro", err)
+		apierror.Respond(c, http.StatusBadGateway, "Provedor de identidade indisponível")
+		return
+	}
+
+	// 2. Gera state (contra CSRF), nonce (contra replay do ID token) e o verifier do PKCE
+	state, stateHash, err := newOpaqueToken()
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	nonce, _, err := newOpaqueToken()
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// 3. Guarda tudo para conferir no callback (o município vem da requisição atual)
+	err = h.store.SaveState(ctx, stateHash, oidcState{
+		TenantID:     tenant.IDFromContext(c),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+	}, time.Now().Add(oidcStateTTL))
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Liga o login a este navegador e redireciona para o provedor
+	h.setStateCookie(c, state, int(oidcStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)))
+}
+
+// Callback é o método para o endpoint GET /api/auth/oidc/callback
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. O provedor pode devolver um erro (ex.: usuário cancelou)
+	if e := c.Query("error"); e != "" {
+		apierror.Respond(c, http.StatusUnauthorized, "Login recusado pelo provedor: "+e)
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetros 'code' e 'state' são obrigatórios")
+		return
+	}
+
+	// 2. O state precisa ser o do cookie gravado no Login (mesmo navegador)
+	cookie, err := c.Cookie(oidcStateCookie)
+	h.setStateCookie(c, "", -1)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		apierror.Respond(c, http.StatusBadRequest, ErrOIDCStateInvalido.Error())
+		return
+	}
+
+	// 3. Confere o state (uma única vez) e recupera o município, o nonce e o verifier
+	st, err := h.store.ConsumeState(ctx, hashToken(state))
+	if err != nil {
+		if errors.Is(err, ErrOIDCStateInvalido) {
+			apierror.Respond(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	oauth, verifier, err := h.discover(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Erro ao carregar o provedor OIDC", "erro", err)
+		apierror.Respond(c, http.StatusBadGateway, "Provedor de identidade indisponível")
+		return
+	}
+
+	// 4. Troca o code pelos tokens do provedor, provando a posse do verifier (PKCE)
+	tok, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(st.CodeVerifier))
+	if err != nil {
+		slog.WarnContext(ctx, "Erro na troca do code OIDC", "erro", err)
+		apierror.Respond(c, http.StatusUnauthorized, "Não foi possível concluir o login no provedor")
+		return
+	}
+
+	// 5. Valida o ID token: assinatura, issuer, audience, validade e nonce
+	rawIDToken, ok := tok.Extra("id_token").(string)
+	if !ok {
+		apierror.Respond(c, http.StatusUnauthorized, "O provedor não retornou um ID token")
+		return
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		slog.WarnContext(ctx, "ID token OIDC inválido", "erro", err)
+		apierror.Respond(c, http.StatusUnauthorized, "ID token inválido")
+		return
+	}
+	if idToken.Nonce != st.Nonce {
+		apierror.Respond(c, http.StatusUnauthorized, "ID token inválido")
+		return
+	}
+	var claims idTokenClaims
+	if err := idToken.Claims(&claims); err != nil {
+		apierror.Respond(c, http.StatusUnauthorized, "ID token inválido")
+		return
+	}
+
+	// 6. Encontra o usuário ligado à identidade (ou liga pelo e-mail verificado)
+	u, status, msg := h.resolveUser(ctx, st.TenantID, idToken.Issuer, idToken.Subject, claims)
+	if u == nil {
+		apierror.Respond(c, status, msg)
+		return
+	}
+	if !u.Ativo {
+		apierror.Respond(c, http.StatusForbidden, "Usuário desativado")
+		return
+	}
+
+	userAgent := c.Request.UserAgent()
+	attempt := LoginAttempt{
+		TenantID:  u.TenantID,
+		Email:     strings.ToLower(u.Email),
+		UserID:    &u.ID,
+		IP:        c.ClientIP(),
+		UserAgent: &userAgent,
+	}
+
+	// 7. O 2FA da API também vale aqui: o login termina em POST /api/auth/login/2fa
+	challenge, err := h.auth.mfaChallenge(ctx, u)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if challenge != nil {
+		attempt.Resultado = ResultadoAguardando2FA
+		h.auth.recordAttempt(c, attempt)
+		if h.opts.SuccessURL != "" {
+			fragment := url.Values{}
+			fragment.Set("mfa_token", challenge.MFAToken)
+			fragment.Set("expires_in", strconv.Itoa(challenge.ExpiresIn))
+			fragment.Set("cadastro_obrigatorio", strconv.FormatBool(challenge.CadastroObrigatorio))
+			c.Redirect(http.StatusFound, h.opts.SuccessURL+"#"+fragment.Encode())
+			return
+		}
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	// 8. Emite os nossos tokens, como num login com senha
+	resp, err := h.auth.startSession(c, u)
+	if err != nil {
+		apierror.Internal(c, fmt.Errorf("abrir sessão: %w", err))
+		return
+	}
+	attempt.Resultado = ResultadoSucesso
+	h.auth.recordAttempt(c, attempt)
+
+	// 9. Entrega os tokens: no fragmento da página do frontend (não vai para logs de servidor) ou em JSON
+	if h.opts.SuccessURL != "" {
+		fragment := url.Values{}
+		fragment.Set("token", resp.Token)
+		fragment.Set("refresh_token", resp.RefreshToken)
+		fragment.Set("expires_in", strconv.Itoa(resp.ExpiresIn))
+		c.Redirect(http.StatusFound, h.opts.SuccessURL+"#"+fragment.Encode())
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// resolveUser mapeia a identidade externa para um usuário do município.
+// Não cria usuários: a pessoa precisa ter sido convidada antes (POST /api/usuarios).
+func (h *OIDCHandler) resolveUser(ctx context.Context, tenantID, issuer, subject string, claims idTokenClaims) (*user.User, int, string) {
+	// 1. Identidade já ligada
+	u, err := h.store.FindUserByIdentity(ctx, tenantID, issuer, subject)
+	if err == nil {
+		return u, 0, ""
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(ctx, "Erro ao resolver usuário do OIDC", "erro", err)
+		return nil, http.StatusInternalServerError, apierror.MensagemInterna
+	}
+
+	// 2. Primeiro login: liga pelo e-mail, desde que o provedor o tenha verificado
+	if claims.Email == "" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		return nil, http.StatusForbidden, "O provedor não informou um e-mail verificado"
+	}
+	u, err = h.auth.userRepo.FindByEmail(ctx, tenantID, strings.ToLower(claims.Email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, http.StatusForbidden, "Usuário não cadastrado neste município"
+		}
+		slog.ErrorContext(ctx, "Erro ao resolver usuário do OIDC", "erro", err)
+		return nil, http.StatusInternalServerError, apierror.MensagemInterna
+	}
+	if err := h.store.LinkIdentity(ctx, tenantID, u.ID, issuer, subject, claims.Email); err != nil {
+		if database.IsUniqueViolation(err) {
+			// A identidade já foi ligada a um usuário (talvez outro) depois da busca acima:
+			// não loga ninguém com base no e-mail; o próximo login a encontra já ligada
+			return nil, http.StatusConflict, "A identidade do provedor já está ligada a um usuário: tente entrar novamente"
+		}
+		slog.ErrorContext(ctx, "Erro ao resolver usuário do OIDC", "erro", err)
+		return nil, http.StatusInternalServerError, apierror.MensagemInterna
+	}
+	return u, 0, ""
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrOIDCStateInvalido é retornado quando o state do callback não existe,
+// expirou ou já foi usado
+var ErrOIDCStateInvalido = errors.New("login expirado ou inválido: comece novamente")
+
+// oidcState é um login OIDC em andamento
+type oidcState struct {
+	TenantID     string `db:"tenant_id"`
+	Nonce        string `db:"nonce"`
+	CodeVerifier string `db:"code_verifier"`
+}
+
+// OIDCStore gerencia os logins OIDC em andamento e as identidades externas ligadas aos usuários
+type OIDCStore struct {
+	db *sqlx.DB
+}
+
+// NewOIDCStore cria uma nova instância do repositório
+func NewOIDCStore(db *sqlx.DB) *OIDCStore {
+	return &OIDCStore{db: db}
+}
+
+// SaveState grava um login em andamento (e aproveita para limpar os expirados)
+func (r *OIDCStore) SaveState(ctx context.Context, stateHash string, st oidcState, expiresAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM oidc_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO oidc_states (state_hash, tenant_id, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		stateHash, st.TenantID, st.Nonce, st.CodeVerifier, expiresAt)
+	return err
+}
+
+// ConsumeState busca e apaga o login em andamento (cada state vale uma vez)
+func (r *OIDCStore) ConsumeState(ctx context.Context, stateHash string) (*oidcState, error) {
+	var st oidcState
+	err := r.db.GetContext(ctx, &st, `
+		DELETE FROM oidc_states
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING tenant_id, nonce, code_verifier`, stateHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOIDCStateInvalido
+		}
+		return nil, err
+	}
+	return &st, nil
+}
+
+// FindUserByIdentity busca o usuário do município ligado à identidade externa
+// e registra o login. Retorna sql.ErrNoRows se a identidade ainda não foi ligada.
+func (r *OIDCStore) FindUserByIdentity(ctx context.Context, tenantID, issuer, subject string) (*user.User, error) {
+	var u user.User
+	err := r.db.GetContext(ctx, &u, `
+		UPDATE user_identities ui SET last_login_at = NOW()
+		FROM users u
+		WHERE
+			u.id = ui.user_id
+			AND ui.tenant_id = $3
+			AND ui.issuer = $1
+			AND ui.subject = $2
+			AND u.tenant_id = $3
+		RETURNING u.id, u.tenant_id, u.email, u.password_hash, u.role, u.ativo, u.created_at, u.updated_at`,
+		issuer, subject, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// LinkIdentity liga a identidade externa ao usuário do município (no primeiro login
+// por OIDC). Retorna um erro de violação de unicidade se ela já estiver ligada
+// a um usuário do município.
+func (r *OIDCStore) LinkIdentity(ctx context.Context, tenantID, userID, issuer, subject, email string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_identities (tenant_id, user_id, issuer, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())`, tenantID, userID, issuer, subject, email)
+	return err
+}
@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ericoliveiras/ecoponto-api/internal/oidcmock"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/lib/pq"
+)
+
+const testRedirectURL = "http://api.test/api/auth/oidc/callback"
+
+// oidcTest é o login OIDC contra o provedor de oidcmock, com o banco falso
+type oidcTest struct {
+	t        *testing.T
+	issuer   string
+	mock     sqlmock.Sqlmock
+	router   http.Handler
+	provider *http.Client // Não segue redirecionamentos: lemos o Location
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+
+	// 1. Provedor mock num servidor local
+	srv := httptest.NewUnstartedServer(nil)
+	issuer := "http://" + srv.Listener.Addr().String()
+	p, err := oidcmock.New(issuer)
+	if err != nil {
+		t.Fatalf("oidcmock: %v", err)
+	}
+	srv.Config.Handler = p
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	// 2. Handler OIDC sobre o banco falso
+	db, mock := newMockDB(t)
+	h := NewOIDCHandler(newTestHandler(t, db, MFAOptions{}), NewOIDCStore(db), OIDCOptions{
+		Issuer:      issuer,
+		ClientID:    "ecoponto-api",
+		RedirectURL: testRedirectURL,
+	})
+	r := newTestRouter()
+	r.GET("/api/auth/oidc/login", h.Login)
+	r.GET("/api/auth/oidc/callback", h.Callback)
+
+	return &oidcTest{
+		t:      t,
+		issuer: issuer,
+		mock:   mock,
+		router: r,
+		provider: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}},
+	}
+}
+
+// oidcLogin é um login iniciado: o state do cookie e o que foi gravado no banco
+type oidcLogin struct {
+	location *url.URL
+	cookie   *http.Cookie
+	nonce    string
+	verifier string
+}
+
+// start chama GET /api/auth/oidc/login
+func (o *oidcTest) start() oidcLogin {
+	o.t.Helper()
+	var l oidcLogin
+	o.mock.ExpectExec(`DELETE FROM oidc_states WHERE expires_at`).WillReturnResult(sqlmock.NewResult(0, 0))
+	o.mock.ExpectExec(`INSERT INTO oidc_states`).
+		WithArgs(sqlmock.AnyArg(), testTenantID, captureArg{&l.nonce}, captureArg{&l.verifier}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	w := httptest.NewRecorder()
+	o.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+	if w.Code != http.StatusFound {
+		o.t.Fatalf("login: status %d, corpo %s", w.Code, w.Body)
+	}
+
+	loc, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		o.t.Fatalf("Location inválido: %v", err)
+	}
+	l.location = loc
+	for _, c := range w.Result().Cookies() {
+		if c.Name == oidcStateCookie {
+			l.cookie = c
+		}
+	}
+	if l.cookie == nil {
+		o.t.Fatal("login não gravou o cookie do state")
+	}
+	return l
+}
+
+// authorize faz o login no provedor mock e retorna code e state do redirecionamento
+func (o *oidcTest) authorize(l oidcLogin, email string) (code, state string) {
+	o.t.Helper()
+	resp, err := o.provider.PostForm(l.location.String(), url.Values{"email": {email}})
+	if err != nil {
+		o.t.Fatalf("authorize: %v", err)
+	}
+	resp.Body.Close()
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		o.t.Fatalf("authorize: status %d, Location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+// callback chama GET /api/auth/oidc/callback com o cookie (se houver)
+func (o *oidcTest) callback(code, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	o.t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?"+url.Values{"code": {code}, "state": {state}}.Encode(), nil)
+	if cookie != nil {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	w := httptest.NewRecorder()
+	o.router.ServeHTTP(w, req)
+	return w
+}
+
+// expectState espera o consumo do state gravado no login
+func (o *oidcTest) expectState(l oidcLogin) {
+	o.mock.ExpectQuery(`DELETE FROM oidc_states\s+WHERE state_hash`).
+		WithArgs(hashToken(l.cookie.Value)).
+		WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "nonce", "code_verifier"}).AddRow(testTenantID, l.nonce, l.verifier))
+}
+
+// expectFirstLogin espera a busca da identidade (ainda não ligada) e do usuário pelo e-mail
+func (o *oidcTest) expectFirstLogin(email string) {
+	o.mock.ExpectQuery(`UPDATE user_identities`).
+		WithArgs(o.issuer, "mock|"+email, testTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	o.mock.ExpectQuery(`SELECT .+ FROM users WHERE tenant_id = \$1 AND email = \$2`).
+		WithArgs(testTenantID, email).
+		WillReturnRows(userRows(user.User{ID: testUserID, TenantID: testTenantID, Email: email, Role: user.RoleEditor, Ativo: true}))
+}
+
+func TestOIDCLoginBindsStateToBrowser(t *testing.T) {
+	o := newOIDCTest(t)
+	l := o.start()
+
+	// O provedor recebe o state do cookie e o desafio PKCE
+	q := l.location.Query()
+	if got := l.location.Scheme + "://" + l.location.Host + l.location.Path; got != o.issuer+"/authorize" {
+		t.Errorf("redirecionou para %s", got)
+	}
+	if q.Get("state") != l.cookie.Value {
+		t.Errorf("state %q diferente do cookie %q", q.Get("state"), l.cookie.Value)
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Errorf("sem PKCE S256: %v", q)
+	}
+	if q.Get("nonce") != l.nonce {
+		t.Errorf("nonce %q diferente do gravado %q", q.Get("nonce"), l.nonce)
+	}
+
+	// O cookie é de vida curta, restrito ao callback e inacessível ao JavaScript
+	c := l.cookie
+	if !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Path != "/api/auth/oidc/callback" {
+		t.Errorf("cookie sem as proteções esperadas: %+v", c)
+	}
+	if c.MaxAge != int(oidcStateTTL.Seconds()) {
+		t.Errorf("MaxAge = %d, esperado %d", c.MaxAge, int(oidcStateTTL.Seconds()))
+	}
+}
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie func(l oidcLogin) *http.Cookie
+	}{
+		{"sem cookie", func(oidcLogin) *http.Cookie { return nil }},
+		{"cookie de outro login", func(l oidcLogin) *http.Cookie {
+			return &http.Cookie{Name: oidcStateCookie, Value: "state-de-outro-navegador"}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOIDCTest(t)
+			l := o.start()
+			code, state := o.authorize(l, testEmail)
+
+			// Sem o cookie certo o state nem é consultado: nenhuma consulta além das do login
+			w := o.callback(code, state, tt.cookie(l))
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+			}
+			if strings.Contains(w.Body.String(), "token") {
+				t.Errorf("resposta com tokens: %s", w.Body)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackLinksIdentityAndLogsIn(t *testing.T) {
+	o := newOIDCTest(t)
+	l := o.start()
+	code, state := o.authorize(l, testEmail)
+
+	o.expectState(l)
+	o.expectFirstLogin(testEmail)
+	o.mock.ExpectExec(`INSERT INTO user_identities`).
+		WithArgs(testTenantID, testUserID, o.issuer, "mock|"+testEmail, testEmail).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	o.mock.ExpectQuery(`FROM user_totp`).WithArgs(testUserID).WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	o.mock.ExpectBegin()
+	o.mock.ExpectQuery(`INSERT INTO sessoes`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "tenant_id", "user_agent", "ip", "created_at"}).
+			AddRow("33333333-3333-3333-3333-333333333333", testUserID, testTenantID, nil, nil, time.Now()))
+	o.mock.ExpectExec(`INSERT INTO refresh_tokens`).WillReturnResult(sqlmock.NewResult(0, 1))
+	o.mock.ExpectCommit()
+	o.mock.ExpectExec(`INSERT INTO login_attempts`).
+		WithArgs(testTenantID, testEmail, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), ResultadoSucesso).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	w := o.callback(code, state, l.cookie)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Token == "" || resp.RefreshToken == "" {
+		t.Fatalf("resposta sem tokens: %s", w.Body)
+	}
+
+	// O cookie do state é apagado no callback
+	for _, c := range w.Result().Cookies() {
+		if c.Name == oidcStateCookie && c.MaxAge >= 0 {
+			t.Errorf("cookie do state não foi apagado: %+v", c)
+		}
+	}
+}
+
+func TestOIDCCallbackRejectsIdentityLinkConflict(t *testing.T) {
+	o := newOIDCTest(t)
+	l := o.start()
+	code, state := o.authorize(l, testEmail)
+
+	// A identidade foi ligada a um usuário entre a busca e o INSERT: ninguém é logado
+	o.expectState(l)
+	o.expectFirstLogin(testEmail)
+	o.mock.ExpectExec(`INSERT INTO user_identities`).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "user_identities_tenant_issuer_subject_key"})
+
+	w := o.callback(code, state, l.cookie)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status %d, esperado 409 (corpo %s)", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "refresh_token") {
+		t.Errorf("resposta com tokens: %s", w.Body)
+	}
+}
+
+func TestOIDCCallbackRejectsUnverifiedPKCE(t *testing.T) {
+	o := newOIDCTest(t)
+	l := o.start()
+	code, state := o.authorize(l, testEmail)
+
+	// O state gravado traz outro verifier: o provedor recusa a troca do code
+	o.mock.ExpectQuery(`DELETE FROM oidc_states\s+WHERE state_hash`).
+		WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "nonce", "code_verifier"}).
+			AddRow(testTenantID, l.nonce, "verifier-de-outro-login-com-pelo-menos-43-caracteres"))
+
+	w := o.callback(code, state, l.cookie)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, esperado 401 (corpo %s)", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// principalKey é a chave do usuário autenticado no contexto do Gin
+const principalKey = "principal"
+
+// Principal é quem está autenticado na requisição: um usuário (JWT)
+// ou um cliente máquina-a-máquina (chave de API)
+type Principal struct {
+	UserID    string
+	TenantID  string
+	Email     string
+	Role      string
+	SessionID string
+
+	// Preenchidos apenas quando a autenticação foi por chave de API
+	APIKeyID string
+	Scopes   []Permission
+}
+
+// IsAPIKey indica se a requisição foi autenticada por chave de API
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != ""
+}
+
+// Can indica se o usuário (pelo papel) ou a chave (pelos scopes) tem a permissão
+func (p *Principal) Can(perm Permission) bool {
+	if p.IsAPIKey() {
+		for _, s := range p.Scopes {
+			if s == perm {
+				return true
+			}
+		}
+		return false
+	}
+	return HasPermission(p.Role, perm)
+}
+
+// SetPrincipal guarda o usuário autenticado no contexto (usado pelo AuthMiddleware)
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+}
+
+// PrincipalFromContext retorna o usuário autenticado,
+// ou nil se a requisição não passou pelo AuthMiddleware
+func PrincipalFromContext(c *gin.Context) *Principal {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	p, _ := v.(*Principal)
+	return p
+}
+
+// UserIDFromContext retorna o ID do usuário autenticado,
+// ou "" se a requisição não passou pelo AuthMiddleware
+func UserIDFromContext(c *gin.Context) string {
+	if p := PrincipalFromContext(c); p != nil {
+		return p.UserID
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package auth
+
+import "github.com/ericoliveiras/ecoponto-api/internal/user"
+
+// Permission é uma ação que pode ser exigida por uma rota
+type Permission string
+
+// Permissões das rotas administrativas
+const (
+	PermEcopontosLer        Permission = "ecopontos:ler"
+	PermEcopontosCriar      Permission = "ecopontos:criar" // Criação e importação em lote
+	PermEcopontosEditar     Permission = "ecopontos:editar"
+	PermEcopontosExcluir    Permission = "ecopontos:excluir"
+	PermOrganizacoesEditar  Permission = "organizacoes:editar" // Criação e edição
+	PermOrganizacoesExcluir Permission = "organizacoes:excluir"
+	PermGeocodingUsar       Permission = "geocoding:usar"  // Busca, reverso e consulta ao cache
+	PermGeocodingCache      Permission = "geocoding:cache" // Consulta e limpeza do cache
+	PermJobsLer             Permission = "jobs:ler"
+	PermUsuariosGerenciar   Permission = "usuarios:gerenciar"
+	PermAPIKeysGerenciar    Permission = "apikeys:gerenciar"
+)
+
+// RolePermissions lista as permissões de cada papel
+var RolePermissions = map[string][]Permission{
+	user.RoleSuperadmin: {
+		PermEcopontosLer, PermEcopontosCriar, PermEcopontosEditar, PermEcopontosExcluir,
+		PermOrganizacoesEditar, PermOrganizacoesExcluir,
+		PermGeocodingUsar, PermGeocodingCache,
+		PermJobsLer,
+		PermUsuariosGerenciar, PermAPIKeysGerenciar,
+	},
+	user.RoleEditor: {
+		PermEcopontosLer, PermEcopontosCriar, PermEcopontosEditar,
+		PermOrganizacoesEditar,
+		PermGeocodingUsar,
+		PermJobsLer,
+	},
+	user.RoleModerator: {
+		PermEcopontosLer, PermEcopontosEditar,
+		PermGeocodingUsar,
+		PermJobsLer,
+	},
+	user.RoleViewer: {
+		PermEcopontosLer,
+		PermJobsLer,
+	},
+}
+
+// ValidRole indica se o papel existe
+func ValidRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+// HasPermission indica se o papel tem a permissão
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidAPIKeyScope indica se a permissão pode ser concedida a uma chave de API.
+// Gerenciar usuários e chaves fica restrito a pessoas.
+func ValidAPIKeyScope(perm Permission) bool {
+	if perm == PermUsuariosGerenciar || perm == PermAPIKeysGerenciar {
+		return false
+	}
+	return HasPermission(user.RoleSuperadmin, perm)
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrResetInvalido é retornado quando o token de redefinição não existe,
+// expirou ou já foi usado
+var ErrResetInvalido = errors.New("token de redefinição inválido ou expirado")
+
+// ResetRepository gerencia os tokens de redefinição de senha
+type ResetRepository struct {
+	db *sqlx.DB
+}
+
+// NewResetRepository cria uma nova instância do repositório
+func NewResetRepository(db *sqlx.DB) *ResetRepository {
+	return &ResetRepository{db: db}
+}
+
+// Create grava um novo token para o usuário, invalidando os anteriores ainda não usados.
+// Retorna false, sem gravar, se o usuário já pediu um token desde recentSince.
+func (r *ResetRepository) Create(ctx context.Context, userID, tokenHash, ip string, expiresAt, recentSince time.Time) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 1. Serializa os pedidos do mesmo usuário e recusa os repetidos
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('reset:' || $1, 0))`, userID); err != nil {
+		return false, err
+	}
+	var recent bool
+	err = tx.GetContext(ctx, &recent, `
+		SELECT EXISTS (SELECT 1 FROM password_resets WHERE user_id = $1 AND created_at > $2)`, userID, recentSince)
+	if err != nil {
+		return false, err
+	}
+	if recent {
+		return false, nil
+	}
+
+	// 2. Invalida os tokens anteriores e grava o novo
+	_, err = tx.ExecContext(ctx, `
+		UPDATE password_resets SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL`, userID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO password_resets (user_id, token_hash, expires_at, ip)
+		VALUES ($1, $2, $3, $4)`, userID, tokenHash, expiresAt, ip)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// ResetPassword consome o token e grava a nova senha na mesma transação.
+// Retorna o ID do usuário, ou ErrResetInvalido.
+func (r *ResetRepository) ResetPassword(ctx context.Context, tenantID, tokenHash, passwordHash string) (string, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	// 1. Marca o token como usado (só se ainda valer e o usuário estiver ativo no município)
+	var userID string
+	err = tx.GetContext(ctx, &userID, `
+		UPDATE password_resets pr SET used_at = NOW()
+		FROM users u
+		WHERE
+			u.id = pr.user_id
+			AND pr.token_hash = $1
+			AND u.tenant_id = $2
+			AND u.ativo
+			AND pr.used_at IS NULL
+			AND pr.expires_at > NOW()
+		RETURNING pr.user_id`, tokenHash, tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrResetInvalido
+		}
+		return "", err
+	}
+
+	// 2. Grava a nova senha
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET password_hash = $2, updated_at = NOW()
+		WHERE id = $1`, userID, passwordHash)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
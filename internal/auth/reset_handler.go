@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/mail"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// resetSendTimeout é o tempo máximo para gerar e enviar o e-mail de redefinição
+const resetSendTimeout = 30 * time.Second
+
+// maxEnviosReset limita os envios em segundo plano simultâneos; com todos ocupados,
+// os novos pedidos são descartados (a resposta continua a mesma)
+const maxEnviosReset = 16
+
+// ResetOptions configura a redefinição de senha
+type ResetOptions struct {
+	URL       string        // Página do frontend que recebe ?token=
+	TTL       time.Duration // Validade do token
+	Intervalo time.Duration // Intervalo mínimo entre dois e-mails para a mesma conta
+}
+
+// ResetHandler gerencia o fluxo de "esqueci minha senha"
+type ResetHandler struct {
+	userRepo *user.Repository
+	resets   *ResetRepository
+	sessions *SessionRepository
+	mailer   mail.Sender
+	opts     ResetOptions
+
+	slots   chan struct{}  // Vagas de envio (semáforo de maxEnviosReset)
+	sending sync.WaitGroup // Envios em segundo plano ainda em andamento
+}
+
+// NewResetHandler cria uma nova instância do handler
+func NewResetHandler(userRepo *user.Repository, resets *ResetRepository, sessions *SessionRepository, mailer mail.Sender, opts ResetOptions) *ResetHandler {
+	return &ResetHandler{
+		userRepo: userRepo,
+		resets:   resets,
+		sessions: sessions,
+		mailer:   mailer,
+		opts:     opts,
+		slots:    make(chan struct{}, maxEnviosReset),
+	}
+}
+
+// ForgotPasswordRequest é o corpo de POST /api/auth/forgot
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest é o corpo de POST /api/auth/reset
+type ResetPasswordRequest struct {
+	Token     string `json:"token" binding:"required"`
+	NovaSenha string `json:"nova_senha" binding:"required,min=8,max=72"`
+}
+
+// ForgotPassword é o método para o endpoint POST /api/auth/forgot.
+// A resposta é sempre a mesma, exista ou não o e-mail, e o envio acontece em
+// segundo plano para o tempo de resposta também não denunciar a conta.
+func (h *ResetHandler) ForgotPassword(c *gin.Context) {
+	// 1. Valida o JSON de entrada
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Gera e envia o token fora da requisição, com um número limitado de envios
+	// simultâneos (o limite por IP fica no middleware da rota). O *gin.Context
+	// volta para o pool quando o handler retorna: a goroutine só usa os valores lidos aqui.
+	t := tenant.FromContext(c)
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	ip := c.ClientIP()
+	reqCtx := c.Request.Context()
+	select {
+	case h.slots <- struct{}{}:
+		h.sending.Add(1)
+		go func() {
+			defer func() {
+				<-h.slots
+				h.sending.Done()
+			}()
+			h.send(reqCtx, t, email, ip)
+		}()
+	default:
+		slog.WarnContext(reqCtx, "Pedido de redefinição de senha descartado: envios em andamento no limite",
+			"limite", maxEnviosReset)
+	}
+
+	// 3. Resposta genérica
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Se o e-mail estiver cadastrado, você receberá as instruções para redefinir a senha.",
+	})
+}
+
+// send executa o envio em segundo plano, desligado do cancelamento da requisição
+func (h *ResetHandler) send(reqCtx context.Context, t *tenant.Tenant, email, ip string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(reqCtx), resetSendTimeout)
+	defer cancel()
+	if err := h.sendReset(ctx, t, email, ip); err != nil {
+		slog.ErrorContext(ctx, "Erro ao enviar e-mail de redefinição de senha", "erro", err)
+	}
+}
+
+// Wait espera os envios em segundo plano terminarem (usado no desligamento,
+// antes de fechar o banco), até o prazo do contexto
+func (h *ResetHandler) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.sending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sendReset gera o token e envia o link, se o e-mail pertencer a um usuário ativo
+func (h *ResetHandler) sendReset(ctx context.Context, t *tenant.Tenant, email, ip string) error {
+	// 1. Busca o usuário; e-mail desconhecido ou conta desativada não recebem nada
+	u, err := h.userRepo.FindByEmail(ctx, t.ID, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if !u.Ativo {
+		return nil
+	}
+
+	// 2. Gera o token (só o hash vai para o banco). Se a conta recebeu um link há
+	// menos de Intervalo, não envia outro: pedidos repetidos não lotam a caixa de entrada
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	created, err := h.resets.Create(ctx, u.ID, hash, ip, now.Add(h.opts.TTL), now.Add(-h.opts.Intervalo))
+	if err != nil {
+		return err
+	}
+	if !created {
+		slog.InfoContext(ctx, "Redefinição de senha já pedida há pouco; e-mail não reenviado", "user_id", u.ID)
+		return nil
+	}
+
+	// 3. Monta o link do frontend e envia
+	link, err := resetLink(h.opts.URL, t.Slug, token)
+	if err != nil {
+		return err
+	}
+	return h.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: fmt.Sprintf("Redefinição de senha — EcoPonto %s", t.Nome),
+		Body: fmt.Sprintf("Olá,\r\n\r\n"+
+			"Recebemos um pedido para redefinir a senha da sua conta no EcoPonto %s.\r\n"+
+			"Para escolher uma nova senha, acesse o link abaixo (válido por %s):\r\n\r\n"+
+			"%s\r\n\r\n"+
+			"Se você não fez este pedido, ignore este e-mail: sua senha continua a mesma.\r\n",
+			t.Nome, h.opts.TTL, link),
+	})
+}
+
+// ResetPassword é o método para o endpoint POST /api/auth/reset.
+// Consome o token, grava a nova senha e encerra todas as sessões do usuário.
+func (h *ResetHandler) ResetPassword(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. Valida o JSON de entrada
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Gera o hash da nova senha
+	hash, err := HashPassword(req.NovaSenha)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 3. Consome o token e troca a senha
+	userID, err := h.resets.ResetPassword(ctx, tenant.IDFromContext(c), hashToken(req.Token), hash)
+	if err != nil {
+		if errors.Is(err, ErrResetInvalido) {
+			apierror.Respond(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Quem tinha a senha antiga perde o acesso
+	if err := h.sessions.RevokeAllForUser(ctx, userID, MotivoSenha); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// resetLink acrescenta o token (e o município) à URL da página de redefinição
+func resetLink(base, tenantSlug, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("PASSWORD_RESET_URL inválida: %w", err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	q.Set("municipio", tenantSlug)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Motivos de revogação de uma sessão
+const (
+	MotivoLogout = "logout"
+	MotivoReuso  = "reuso" // Um refresh token já usado foi apresentado de novo
+	MotivoSenha  = "senha" // A senha do usuário foi trocada
+	MotivoConta  = "conta" // A conta foi desativada ou o papel mudou
+	MotivoMFA    = "2fa"   // O 2FA foi ativado ou resetado por um administrador
+)
+
+var (
+	// ErrRefreshInvalido é retornado quando o refresh token não existe, expirou
+	// ou pertence a uma sessão encerrada
+	ErrRefreshInvalido = errors.New("refresh token inválido ou expirado")
+
+	// ErrRefreshReutilizado é retornado quando um refresh token já trocado é usado de novo.
+	// Isso indica que ele vazou, então a sessão inteira é revogada.
+	ErrRefreshReutilizado = errors.New("refresh token reutilizado: sessão encerrada")
+)
+
+// Session é uma sessão aberta no login; todos os refresh tokens dela formam uma família
+type Session struct {
+	ID        string    `db:"id" json:"id"`
+	UserID    string    `db:"user_id" json:"user_id"`
+	TenantID  string    `db:"tenant_id" json:"tenant_id"`
+	UserAgent *string   `db:"user_agent" json:"user_agent,omitempty"`
+	IP        *string   `db:"ip" json:"ip,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// SessionRepository gerencia a persistência das sessões e refresh tokens
+type SessionRepository struct {
+	db *sqlx.DB
+}
+
+// NewSessionRepository cria uma nova instância do repositório
+func NewSessionRepository(db *sqlx.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create abre uma sessão junto com o seu primeiro refresh token
+func (r *SessionRepository) Create(ctx context.Context, s Session, tokenHash string, expiresAt time.Time) (*Session, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO sessoes (user_id, tenant_id, user_agent, ip)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, tenant_id, user_agent, ip, created_at`,
+		s.UserID, s.TenantID, s.UserAgent, s.IP,
+	).StructScan(&s)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (sessao_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`, s.ID, tokenHash, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Rotate troca um refresh token por um novo da mesma sessão.
+// Cada token só pode ser usado uma vez: se um token já trocado voltar,
+// a sessão é revogada e ErrRefreshReutilizado é retornado.
+func (r *SessionRepository) Rotate(ctx context.Context, tenantID, tokenHash, newHash string, expiresAt time.Time) (*Session, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Busca e trava o token (duas trocas simultâneas do mesmo token não passam juntas)
+	var tok struct {
+		ID        string       `db:"id"`
+		SessaoID  string       `db:"sessao_id"`
+		ExpiresAt time.Time    `db:"expires_at"`
+		UsedAt    sql.NullTime `db:"used_at"`
+		RevokedAt sql.NullTime `db:"revoked_at"`
+	}
+	err = tx.GetContext(ctx, &tok, `
+		SELECT rt.id, rt.sessao_id, rt.expires_at, rt.used_at, s.revoked_at
+		FROM refresh_tokens rt
+		JOIN sessoes s ON s.id = rt.sessao_id
+		WHERE rt.token_hash = $1 AND s.tenant_id = $2
+		FOR UPDATE OF rt, s`, tokenHash, tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRefreshInvalido
+		}
+		return nil, err
+	}
+
+	// 2. Sessão encerrada ou token vencido
+	if tok.RevokedAt.Valid || time.Now().After(tok.ExpiresAt) {
+		return nil, ErrRefreshInvalido
+	}
+
+	// 3. Token já usado: alguém tem uma cópia dele. Revoga a família inteira.
+	if tok.UsedAt.Valid {
+		if err := revokeSession(ctx, tx, tok.SessaoID, MotivoReuso); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshReutilizado
+	}
+
+	// 4. Marca o token atual como usado e emite o próximo da família
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1`, tok.ID); err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (sessao_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)`, tok.SessaoID, newHash, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Session
+	err = tx.GetContext(ctx, &s, `
+		SELECT id, user_id, tenant_id, user_agent, ip, created_at
+		FROM sessoes WHERE id = $1`, tok.SessaoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// RevokeByToken encerra a sessão à qual o refresh token pertence (logout).
+// Retorna sql.ErrNoRows se o token não existir.
+func (r *SessionRepository) RevokeByToken(ctx context.Context, tenantID, tokenHash string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE sessoes s
+		SET revoked_at = COALESCE(s.revoked_at, NOW()), motivo_revogacao = COALESCE(s.motivo_revogacao, $3)
+		FROM refresh_tokens rt
+		WHERE rt.sessao_id = s.id AND rt.token_hash = $1 AND s.tenant_id = $2`,
+		tokenHash, tenantID, MotivoLogout)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Revoke encerra uma sessão pelo ID
+func (r *SessionRepository) Revoke(ctx context.Context, sessionID, motivo string) error {
+	return revokeSession(ctx, r.db, sessionID, motivo)
+}
+
+// RevokeAllForUser encerra todas as sessões abertas do usuário
+// (ex.: troca de senha ou conta desativada)
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID, motivo string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sessoes SET revoked_at = NOW(), motivo_revogacao = $2
+		WHERE user_id = $1 AND revoked_at IS NULL`, userID, motivo)
+	return err
+}
+
+// IsActive indica se a sessão existe e não foi revogada
+func (r *SessionRepository) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	var active bool
+	err := r.db.GetContext(ctx, &active, `
+		SELECT EXISTS (SELECT 1 FROM sessoes WHERE id = $1 AND revoked_at IS NULL)`, sessionID)
+	return active, err
+}
+
+// revokeSession marca a sessão como revogada (mantendo o primeiro motivo registrado)
+func revokeSession(ctx context.Context, db sqlx.ExecerContext, sessionID, motivo string) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE sessoes SET revoked_at = NOW(), motivo_revogacao = $2
+		WHERE id = $1 AND revoked_at IS NULL`, sessionID, motivo)
+	return err
+}
+
+// RevokeOthersForUser encerra as sessões do usuário, exceto a informada
+// (ex.: troca da própria senha mantém o dispositivo atual logado)
+func (r *SessionRepository) RevokeOthersForUser(ctx context.Context, userID, keepSessionID, motivo string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sessoes SET revoked_at = NOW(), motivo_revogacao = $3
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`, userID, keepSessionID, motivo)
+	return err
+}
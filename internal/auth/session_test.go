@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+)
+
+const testSessionID = "33333333-3333-3333-3333-333333333333"
+
+// refreshTokenRows é o refresh token lido (e travado) por Rotate
+func refreshTokenRows(expiresAt time.Time, usedAt, revokedAt any) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "sessao_id", "expires_at", "used_at", "revoked_at"}).
+		AddRow("44444444-4444-4444-4444-444444444444", testSessionID, expiresAt, usedAt, revokedAt)
+}
+
+// postRefresh chama POST /api/auth/refresh com o refresh token
+func postRefresh(t *testing.T, h *Handler, refreshToken string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := newTestRouter()
+	r.POST("/api/auth/refresh", h.Refresh)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", strings.NewReader(`{"refresh_token": "`+refreshToken+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{})
+
+	// O token atual é marcado como usado e o próximo da família é gravado
+	var newHash string
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM refresh_tokens rt`).
+		WithArgs(hashToken("refresh-atual"), testTenantID).
+		WillReturnRows(refreshTokenRows(time.Now().Add(time.Hour), nil, nil))
+	mock.ExpectExec(`UPDATE refresh_tokens SET used_at = NOW\(\)`).
+		WithArgs("44444444-4444-4444-4444-444444444444").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO refresh_tokens`).
+		WithArgs(testSessionID, captureArg{&newHash}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM sessoes WHERE id = \$1`).
+		WithArgs(testSessionID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "tenant_id", "user_agent", "ip", "created_at"}).
+			AddRow(testSessionID, testUserID, testTenantID, nil, nil, time.Now()))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM users WHERE tenant_id = \$1 AND id = \$2`).
+		WithArgs(testTenantID, testUserID).
+		WillReturnRows(userRows(user.User{ID: testUserID, TenantID: testTenantID, Email: testEmail, Role: user.RoleEditor, Ativo: true}))
+
+	w := postRefresh(t, h, "refresh-atual")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+	var resp TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Token == "" {
+		t.Fatalf("resposta sem tokens: %s", w.Body)
+	}
+
+	// Só o hash do novo refresh token vai para o banco
+	if resp.RefreshToken == "refresh-atual" || hashToken(resp.RefreshToken) != newHash {
+		t.Errorf("refresh token devolvido não é o gravado")
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{})
+
+	// O token já foi trocado antes: alguém tem uma cópia dele
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM refresh_tokens rt`).
+		WillReturnRows(refreshTokenRows(time.Now().Add(time.Hour), time.Now().Add(-time.Minute), nil))
+	mock.ExpectExec(`UPDATE sessoes SET revoked_at = NOW\(\)`).
+		WithArgs(testSessionID, MotivoReuso).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	w := postRefresh(t, h, "refresh-copiado")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, esperado 401 (corpo %s)", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), ErrRefreshReutilizado.Error()) {
+		t.Errorf("corpo %s", w.Body)
+	}
+}
+
+func TestRotateRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		rows *sqlmock.Rows
+	}{
+		{"token desconhecido", sqlmock.NewRows([]string{"id", "sessao_id", "expires_at", "used_at", "revoked_at"})},
+		{"token vencido", refreshTokenRows(time.Now().Add(-time.Minute), nil, nil)},
+		{"sessão encerrada", refreshTokenRows(time.Now().Add(time.Hour), nil, time.Now().Add(-time.Minute))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+
+			// Nada é gravado: a transação é desfeita
+			mock.ExpectBegin()
+			mock.ExpectQuery(`FROM refresh_tokens rt`).WillReturnRows(tt.rows)
+			mock.ExpectRollback()
+
+			_, err := NewSessionRepository(db).Rotate(t.Context(), testTenantID, hashToken("refresh"), hashToken("novo"), time.Now().Add(time.Hour))
+			if !errors.Is(err, ErrRefreshInvalido) {
+				t.Errorf("erro = %v, esperado ErrRefreshInvalido", err)
+			}
+		})
+	}
+}
+
+func TestLogoutRevokesSessionOfToken(t *testing.T) {
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{})
+	r := newTestRouter()
+	r.POST("/api/auth/logout", h.Logout)
+
+	// Token conhecido e desconhecido respondem igual: o cliente fica deslogado
+	mock.ExpectExec(`UPDATE sessoes s`).
+		WithArgs(hashToken("refresh-atual"), testTenantID, MotivoLogout).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE sessoes s`).
+		WithArgs(hashToken("refresh-desconhecido"), testTenantID, MotivoLogout).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	for _, token := range []string{"refresh-atual", "refresh-desconhecido"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/logout", strings.NewReader(`{"refresh_token": "`+token+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s: status %d, esperado 204 (corpo %s)", token, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parâmetros do TOTP (RFC 6238), os padrões aceitos por todos os aplicativos autenticadores
+const (
+	totpPeriodo = 30 // Segundos de cada passo
+	totpDigitos = 6
+	totpJanela  = 1 // Passos aceitos antes e depois do atual (relógio do celular adiantado/atrasado)
+)
+
+// quantidadeCodigosRecuperacao é quantos códigos de recuperação são gerados de cada vez
+const quantidadeCodigosRecuperacao = 10
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateTOTPSecret gera um segredo aleatório de 160 bits, em base32
+func generateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// totpCode calcula o código de 6 dígitos do passo (HOTP, RFC 4226, com HMAC-SHA1)
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Truncamento dinâmico: 4 bytes a partir do offset indicado pelo último nibble
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigitos, bin%1_000_000), nil
+}
+
+// verifyTOTP confere o código contra os passos vizinhos ao instante informado.
+// Retorna o passo que bateu, para o chamador impedir que ele seja reutilizado.
+func verifyTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigitos {
+		return 0, false
+	}
+
+	current := now.Unix() / totpPeriodo
+	for i := int64(-totpJanela); i <= totpJanela; i++ {
+		expected, err := totpCode(secret, current+i)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return current + i, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI monta a URI otpauth:// que os aplicativos autenticadores leem pelo QR code
+func totpURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigitos))
+	q.Set("period", fmt.Sprint(totpPeriodo))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// generateRecoveryCodes gera os códigos de recuperação (formato XXXXX-XXXXX)
+// e os hashes que devem ser guardados no banco no lugar deles
+func generateRecoveryCodes() (codes, hashes []string, err error) {
+	for range quantidadeCodigosRecuperacao {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		s := totpEncoding.EncodeToString(b)[:10]
+		codes = append(codes, s[:5]+"-"+s[5:])
+		hashes = append(hashes, hashRecoveryCode(s))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normaliza o código digitado (sem hífen, maiúsculas) e calcula o hash
+func hashRecoveryCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashToken(code)
+}
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+)
+
+// rfcSecret é o segredo dos vetores de teste da RFC 6238 ("12345678901234567890" em base32)
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// Vetores SHA1 da RFC 6238 (apêndice B), com os 6 últimos dígitos
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rfcSecret, tt.unix/totpPeriodo)
+		if err != nil {
+			t.Fatalf("totpCode: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("T=%d: código %s, esperado %s", tt.unix, got, tt.want)
+		}
+	}
+
+	if _, err := totpCode("não é base32!", 1); err == nil {
+		t.Error("segredo inválido aceito")
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	step := now.Unix() / totpPeriodo
+	code := func(s int64) string {
+		c, err := totpCode(rfcSecret, s)
+		if err != nil {
+			t.Fatalf("totpCode: %v", err)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+		step int64
+	}{
+		{"passo atual", code(step), true, step},
+		{"relógio atrasado", code(step - 1), true, step - 1},
+		{"relógio adiantado", code(step + 1), true, step + 1},
+		{"fora da janela", code(step - 2), false, 0},
+		{"com espaços", " " + code(step) + " ", true, step},
+		{"tamanho errado", code(step)[:5], false, 0},
+		{"vazio", "", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := verifyTOTP(rfcSecret, tt.code, now)
+			if ok != tt.ok || got != tt.step {
+				t.Errorf("verifyTOTP = (%d, %v), esperado (%d, %v)", got, ok, tt.step, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+	if len(codes) != quantidadeCodigosRecuperacao || len(hashes) != len(codes) {
+		t.Fatalf("%d códigos e %d hashes", len(codes), len(hashes))
+	}
+
+	seen := make(map[string]bool)
+	for i, c := range codes {
+		if len(c) != 11 || c[5] != '-' || seen[c] {
+			t.Errorf("código %q inválido ou repetido", c)
+		}
+		seen[c] = true
+
+		// O código é aceito do jeito que o usuário digitar
+		for _, digitado := range []string{c, strings.ToLower(c), strings.ReplaceAll(c, "-", " ")} {
+			if hashRecoveryCode(digitado) != hashes[i] {
+				t.Errorf("%q não confere com o hash de %q", digitado, c)
+			}
+		}
+	}
+}
+
+// postJSON chama a rota com o corpo informado
+func postJSON(h http.Handler, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginMFAConsumesChallengeAttempt(t *testing.T) {
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{})
+	r := newTestRouter()
+	r.POST("/api/auth/login/2fa", h.LoginMFA)
+
+	// A tentativa é gasta antes de o código ser conferido; com as tentativas
+	// esgotadas (ou o desafio expirado) o UPDATE não encontra o desafio
+	mock.ExpectQuery(`UPDATE mfa_challenges SET tentativas = tentativas \+ 1`).
+		WithArgs(hashToken("mfa-token"), testTenantID, maxTentativasDesafio).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+	w := postJSON(r, "/api/auth/login/2fa", `{"mfa_token": "mfa-token", "codigo": "123456"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, esperado 401 (corpo %s)", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), ErrDesafioInvalido.Error()) {
+		t.Errorf("corpo %s", w.Body)
+	}
+}
+
+func TestLoginMFAEnrollRequiresEnrollmentCode(t *testing.T) {
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{Issuer: "Ecoponto"})
+	r := newTestRouter()
+	r.POST("/api/auth/login/2fa/cadastro", h.LoginMFAEnroll)
+
+	// 1. Sem o código emitido por um administrador a senha sozinha não basta
+	if w := postJSON(r, "/api/auth/login/2fa/cadastro", `{"mfa_token": "mfa-token"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+	}
+
+	// 2. Código que não confere: nenhum segredo é gravado
+	mock.ExpectQuery(`UPDATE mfa_challenges SET tentativas = tentativas \+ 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(testUserID))
+	mock.ExpectQuery(`FROM users WHERE tenant_id = \$1 AND id = \$2`).
+		WithArgs(testTenantID, testUserID).
+		WillReturnRows(userRows(user.User{ID: testUserID, TenantID: testTenantID, Email: testEmail, Role: user.RoleSuperadmin, Ativo: true}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM mfa_enrollment_codes`).
+		WithArgs(testUserID, hashToken("CODIGO-ERRADO")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	w := postJSON(r, "/api/auth/login/2fa/cadastro", `{"mfa_token": "mfa-token", "codigo_cadastro": " CODIGO-ERRADO "}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, esperado 401 (corpo %s)", w.Code, w.Body)
+	}
+
+	// 3. Código certo: consumido junto com a gravação do segredo pendente
+	mock.ExpectQuery(`UPDATE mfa_challenges SET tentativas = tentativas \+ 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(testUserID))
+	mock.ExpectQuery(`FROM users WHERE tenant_id = \$1 AND id = \$2`).
+		WillReturnRows(userRows(user.User{ID: testUserID, TenantID: testTenantID, Email: testEmail, Role: user.RoleSuperadmin, Ativo: true}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM mfa_enrollment_codes`).
+		WithArgs(testUserID, hashToken("CODIGO-CERTO")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO user_totp`).
+		WithArgs(testUserID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	w = postJSON(r, "/api/auth/login/2fa/cadastro", `{"mfa_token": "mfa-token", "codigo_cadastro": "CODIGO-CERTO"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+	if !strings.Contains(w.Body.String(), "otpauth://totp/") {
+		t.Errorf("resposta sem a URI do aplicativo: %s", w.Body)
+	}
+}
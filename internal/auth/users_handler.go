@@ -0,0 +1,381 @@
+package auth
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/database"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+// UserHandler gerencia as requisições de administração de usuários
+type UserHandler struct {
+	userRepo *user.Repository
+	sessions *SessionRepository
+	attempts *AttemptRepository
+}
+
+// NewUserHandler cria uma nova instância do handler
+func NewUserHandler(userRepo *user.Repository, sessions *SessionRepository, attempts *AttemptRepository) *UserHandler {
+	return &UserHandler{userRepo: userRepo, sessions: sessions, attempts: attempts}
+}
+
+// InviteUserRequest é o corpo de POST /api/usuarios.
+// Sem senha, uma senha temporária é gerada e devolvida uma única vez.
+type InviteUserRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Role     string `json:"role" binding:"required,oneof=superadmin editor moderator viewer"`
+	Password string `json:"password" binding:"omitempty,min=8,max=72"`
+}
+
+// UpdateUserRequest é o corpo de PUT /api/usuarios/:id (campos nil não mudam)
+type UpdateUserRequest struct {
+	Email *string `json:"email" binding:"omitempty,email,max=255"`
+	Role  *string `json:"role" binding:"omitempty,oneof=superadmin editor moderator viewer"`
+	Ativo *bool   `json:"ativo"`
+}
+
+// ChangePasswordRequest é o corpo de PUT /api/auth/senha
+type ChangePasswordRequest struct {
+	SenhaAtual string `json:"senha_atual" binding:"required"`
+	NovaSenha  string `json:"nova_senha" binding:"required,min=8,max=72"`
+}
+
+// ListUsers é o método para o endpoint GET /api/usuarios.
+// Query params: q (trecho do email), role, ativo, limite (padrão 50, máx. 200), offset.
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	// 1. Ler a paginação
+	limite, err := strconv.Atoi(c.DefaultQuery("limite", "50"))
+	if err != nil || limite <= 0 || limite > 200 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'limite' inválido (1 a 200)")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'offset' inválido")
+		return
+	}
+
+	// 2. Ler os filtros
+	params := user.ListParams{
+		Busca:  c.Query("q"),
+		Role:   c.Query("role"),
+		Limite: limite,
+		Offset: offset,
+	}
+	if params.Role != "" && !ValidRole(params.Role) {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'role' inválido")
+		return
+	}
+	if str := c.Query("ativo"); str != "" {
+		ativo, err := strconv.ParseBool(str)
+		if err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'ativo' inválido (use true ou false)")
+			return
+		}
+		params.Ativo = &ativo
+	}
+
+	// 3. Chamar o repositório
+	page, err := h.userRepo.List(c.Request.Context(), tenant.IDFromContext(c), params)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetUser é o método para o endpoint GET /api/usuarios/:id
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	u, err := h.userRepo.FindByID(c.Request.Context(), tenant.IDFromContext(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, u)
+}
+
+// InviteUser é o método para o endpoint POST /api/usuarios
+func (h *UserHandler) InviteUser(c *gin.Context) {
+	// 1. Faz o "bind" do JSON
+	var req InviteUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Usa a senha enviada ou gera uma temporária
+	password := req.Password
+	temporaria := password == ""
+	if temporaria {
+		var err error
+		if password, err = generatePassword(); err != nil {
+			apierror.Internal(c, err)
+			return
+		}
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 3. Cria o usuário no município atual
+	u, err := h.userRepo.Create(c.Request.Context(), user.User{
+		TenantID:     tenant.IDFromContext(c),
+		Email:        strings.ToLower(strings.TrimSpace(req.Email)),
+		PasswordHash: hash,
+		Role:         req.Role,
+	})
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			apierror.Respond(c, http.StatusConflict, "Já existe um usuário com este email")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Retorna o usuário (e a senha temporária, que não poderá ser consultada depois)
+	if temporaria {
+		c.JSON(http.StatusCreated, gin.H{"usuario": u, "senha_temporaria": password})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"usuario": u})
+}
+
+// UpdateUser é o método para o endpoint PUT /api/usuarios/:id.
+// Mudar o papel ou desativar a conta encerra as sessões abertas do usuário.
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	// 1. Faz o "bind" do JSON
+	var req UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*req.Email))
+		req.Email = &email
+	}
+
+	// 2. Um admin não pode se rebaixar nem se desativar (o município ficaria sem superadmin)
+	if id == UserIDFromContext(c) {
+		if (req.Role != nil && *req.Role != user.RoleSuperadmin) || (req.Ativo != nil && !*req.Ativo) {
+			apierror.Respond(c, http.StatusBadRequest, "Não é possível alterar o próprio papel ou desativar a própria conta")
+			return
+		}
+	}
+
+	// 3. Busca o estado atual, para saber se as sessões precisam ser encerradas
+	tenantID := tenant.IDFromContext(c)
+	atual, err := h.userRepo.FindByID(ctx, tenantID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Chama o repositório
+	u, err := h.userRepo.Update(ctx, tenantID, id, user.Update{Email: req.Email, Role: req.Role, Ativo: req.Ativo})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		if database.IsUniqueViolation(err) {
+			apierror.Respond(c, http.StatusConflict, "Já existe um usuário com este email")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 5. Permissões reduzidas valem na hora: os tokens emitidos deixam de valer
+	if u.Role != atual.Role || (atual.Ativo && !u.Ativo) {
+		if err := h.sessions.RevokeAllForUser(ctx, u.ID, MotivoConta); err != nil {
+			apierror.Internal(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, u)
+}
+
+// DeleteUser é o método para o endpoint DELETE /api/usuarios/:id
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	if id == UserIDFromContext(c) {
+		apierror.Respond(c, http.StatusBadRequest, "Não é possível apagar a própria conta")
+		return
+	}
+
+	err := h.userRepo.Delete(c.Request.Context(), tenant.IDFromContext(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// UnlockUser é o método para o endpoint POST /api/usuarios/:id/desbloquear.
+// Zera as falhas de login da conta (o bloqueio por IP continua valendo).
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := tenant.IDFromContext(c)
+
+	// 1. Busca o usuário
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	u, err := h.userRepo.FindByID(ctx, tenantID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 2. Registra o desbloqueio: as falhas anteriores a ele deixam de contar
+	userAgent := c.Request.UserAgent()
+	err = h.attempts.Record(ctx, LoginAttempt{
+		TenantID:  tenantID,
+		Email:     strings.ToLower(u.Email),
+		UserID:    &u.ID,
+		IP:        c.ClientIP(),
+		UserAgent: &userAgent,
+		Resultado: ResultadoDesbloqueio,
+	})
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListLoginAttempts é o método para o endpoint GET /api/usuarios/:id/tentativas.
+// Query param: limite (padrão 50, máx. 500).
+func (h *UserHandler) ListLoginAttempts(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenantID := tenant.IDFromContext(c)
+
+	limite, err := strconv.Atoi(c.DefaultQuery("limite", "50"))
+	if err != nil || limite <= 0 || limite > 500 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'limite' inválido (1 a 500)")
+		return
+	}
+
+	id, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	u, err := h.userRepo.FindByID(ctx, tenantID, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	attempts, err := h.attempts.ListByEmail(ctx, tenantID, strings.ToLower(u.Email), limite)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, attempts)
+}
+
+// ChangePassword é o método para o endpoint PUT /api/auth/senha.
+// Troca a senha do próprio usuário e encerra as suas outras sessões.
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	ctx := c.Request.Context()
+	p := PrincipalFromContext(c)
+
+	// 1. Faz o "bind" do JSON
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Confere a senha atual
+	u, err := h.userRepo.FindByID(ctx, p.TenantID, p.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+	if !CheckPassword(u.PasswordHash, req.SenhaAtual) {
+		apierror.Respond(c, http.StatusBadRequest, "Senha atual incorreta")
+		return
+	}
+
+	// 3. Grava a nova senha
+	hash, err := HashPassword(req.NovaSenha)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	if err := h.userRepo.UpdatePassword(ctx, p.TenantID, p.UserID, hash); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Encerra as outras sessões (mantém o dispositivo atual)
+	if err := h.sessions.RevokeOthersForUser(ctx, p.UserID, p.SessionID, MotivoSenha); err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// userIDParam lê o :id da URL. Um id que não é UUID não existe no banco,
+// então responde 404 (em vez de deixar o Postgres recusar o valor com um 500).
+func userIDParam(c *gin.Context) (string, bool) {
+	var uri struct {
+		ID string `uri:"id" binding:"uuid"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		apierror.Respond(c, http.StatusNotFound, "Usuário não encontrado")
+		return "", false
+	}
+	return uri.ID, true
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newUsersRouter monta as rotas de usuários sobre o banco falso
+func newUsersRouter(t *testing.T) (http.Handler, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := newMockDB(t)
+	h := newTestHandler(t, db, MFAOptions{})
+	uh := NewUserHandler(h.userRepo, h.sessions, NewAttemptRepository(db))
+	r := newTestRouter()
+	r.GET("/api/usuarios", uh.ListUsers)
+	r.GET("/api/usuarios/:id", uh.GetUser)
+	r.PUT("/api/usuarios/:id", uh.UpdateUser)
+	r.DELETE("/api/usuarios/:id", uh.DeleteUser)
+	return r, mock
+}
+
+func TestUsersRejectMalformedID(t *testing.T) {
+	r, _ := newUsersRouter(t)
+
+	// Um id que não é UUID não chega ao banco: 404, não 500
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/usuarios/nao-e-uuid", strings.NewReader(`{"ativo": false}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, esperado 404 (corpo %s)", method, w.Code, w.Body)
+		}
+	}
+}
+
+func TestListUsersEscapesLikeWildcards(t *testing.T) {
+	r, mock := newUsersRouter(t)
+
+	// "%" e "_" na busca são texto, não curingas
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users WHERE \(tenant_id = \$1 AND email ILIKE \$2\)`).
+		WithArgs(testTenantID, `%ana\_silva\%\\%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`FROM users WHERE`).
+		WithArgs(testTenantID, `%ana\_silva\%\\%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/api/usuarios?q=ana_silva%25%5C`, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+}
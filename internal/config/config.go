@@ -2,7 +2,16 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/logging"
 )
 
 // Config armazena todas as configurações da aplicação
@@ -10,6 +19,108 @@ type Config struct {
 	APIPort     string
 	DatabaseURL string
 	JWTSecret   string
+
+	// Servidor HTTP
+	HTTPReadTimeout       time.Duration // Leitura da requisição inteira
+	HTTPReadHeaderTimeout time.Duration // Leitura dos headers
+	HTTPWriteTimeout      time.Duration // Escrita da resposta
+	HTTPIdleTimeout       time.Duration // Conexões keep-alive ociosas
+	HTTPMaxHeaderBytes    int
+	ShutdownTimeout       time.Duration // Prazo para drenar as requisições no SIGTERM
+	TLSCertFile           string        // Certificado PEM (vazio = sem TLS)
+	TLSKeyFile            string        // Chave privada PEM do certificado
+	TrustedProxies        []string      // IPs/CIDRs dos proxies cujo X-Forwarded-For é aceito (vazio = nenhum)
+
+	// Assinatura assimétrica do JWT (opcional; sem ela, HS256 com o JWTSecret)
+	JWTSigningKeyFile string   // PEM da chave privada RSA ou Ed25519
+	JWTVerifyKeyFiles []string // PEMs das chaves públicas antigas ainda aceitas
+	JWTAcceptHS256    bool     // Com a chave privada, continua aceitando os tokens HS256 (migração)
+
+	// Tokens de acesso
+	AccessTokenTTL  time.Duration // Validade do JWT (curta)
+	RefreshTokenTTL time.Duration // Validade de cada refresh token
+
+	// Login pelo provedor de identidade (OIDC); vazio = desativado
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string   // URL pública de /api/auth/oidc/callback
+	OIDCScopes       []string // Além de "openid"
+	OIDCSuccessURL   string   // Página do frontend que recebe os tokens (#token=...)
+
+	// Autenticação em dois fatores (TOTP)
+	MFAIssuer        string        // Nome exibido no aplicativo autenticador
+	MFARequiredRoles []string      // Papéis que só entram com 2FA (vazio = 2FA opcional para todos)
+	MFAChallengeTTL  time.Duration // Tempo para digitar o código depois da senha
+	MFACadastroTTL   time.Duration // Validade do código de cadastro emitido por um administrador
+
+	// Proteção do login contra força bruta
+	LoginJanela           time.Duration // Período em que as falhas são contadas
+	LoginTentativasLivres int           // Falhas seguidas sem atraso
+	LoginAtrasoBase       time.Duration // Primeiro atraso (dobra a cada falha)
+	LoginMaxFalhasConta   int           // Falhas seguidas que bloqueiam a conta
+	LoginMaxFalhasIP      int           // Falhas de um IP que o bloqueiam
+	LoginBloqueio         time.Duration // Duração do bloqueio
+	LoginRetencao         time.Duration // Por quanto tempo as tentativas ficam na auditoria
+
+	// Redefinição de senha
+	PasswordResetURL       string        // Página do frontend que recebe ?token=
+	PasswordResetTTL       time.Duration // Validade do link enviado por e-mail
+	PasswordResetPorIP     int           // Pedidos por hora aceitos de um mesmo IP
+	PasswordResetIntervalo time.Duration // Intervalo mínimo entre dois e-mails para a mesma conta
+
+	// E-mail
+	MailDriver   string // smtp, ou log/file com MailDev
+	MailDev      bool   // Modo de desenvolvimento: libera os drivers log e file
+	MailFrom     string // Remetente
+	MailDir      string // Pasta usada pelo driver file
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	SMTPTimeout  time.Duration // Prazo de cada envio (conexão e conversa com o servidor)
+
+	// DefaultTenant é o slug do município usado quando a requisição
+	// não identifica nenhum (nem pelo prefixo /t/:tenant, nem pelo Host)
+	DefaultTenant string
+
+	// Geocoding
+	GeocoderProvider   string // nominatim (padrão), photon ou static
+	GeocoderURL        string // URL base do provedor (vazio = padrão do provedor)
+	GeocoderUserAgent  string // User-Agent enviado ao provedor
+	GeocoderStaticFile string // Arquivo JSON usado pelo provedor static
+
+	// Cache de geocoding
+	GeocoderCacheTTL         time.Duration // Validade dos endereços encontrados
+	GeocoderCacheNegativeTTL time.Duration // Validade dos endereços não encontrados
+
+	// Cliente HTTP do geocoding
+	GeocoderTimeout          time.Duration // Tempo máximo de cada chamada ao provedor
+	GeocoderRateLimit        float64       // Chamadas por segundo ao provedor (Nominatim público: 1)
+	GeocoderMaxRetries       int           // Repetições em 429/5xx/erro de rede
+	GeocoderBreakerThreshold int           // Falhas seguidas até suspender as chamadas
+	GeocoderBreakerCooldown  time.Duration // Tempo com as chamadas suspensas
+
+	// Fila de jobs em segundo plano
+	JobWorkers       int           // Workers processando itens (0 desliga o processamento)
+	JobPollInterval  time.Duration // Espera quando a fila está vazia
+	JobMaxTentativas int           // Tentativas até mandar um item para revisão manual
+
+	// Token exigido em GET /metrics (vazio = aberto, para scrape dentro da rede interna)
+	MetricsToken string
+
+	// Health checks (/readyz)
+	HealthCheckTimeout    time.Duration // Prazo de cada verificação
+	HealthGeocoderCache   time.Duration // Por quanto tempo o resultado da verificação do geocoding é reaproveitado
+	HealthGeocoderCritico bool          // Se true, o geocoding fora do ar deixa a API "não pronta" (503)
+
+	// Nível mínimo dos logs (debug, info, warn, error)
+	LogLevel slog.Level
+
+	// OpenTelemetry (traces)
+	OTLPEndpoint       string  // URL do coletor OTLP/HTTP; vazio desliga o envio dos traces
+	TracingServiceName string  // service.name dos spans
+	TracingSampleRatio float64 // Fração dos traces gravados (0 a 1)
 }
 
 // LoadConfig lê a configuração das variáveis de ambiente
@@ -24,16 +135,406 @@ func LoadConfig() (Config, error) {
 		apiPort = "8080"
 	}
 
+	httpReadTimeout, err := getDuration("HTTP_READ_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	httpReadHeaderTimeout, err := getDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	httpWriteTimeout, err := getDuration("HTTP_WRITE_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	httpIdleTimeout, err := getDuration("HTTP_IDLE_TIMEOUT", 120*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	httpMaxHeaderBytes, err := getInt("HTTP_MAX_HEADER_BYTES", 1<<20)
+	if err != nil {
+		return Config{}, err
+	}
+	shutdownTimeout, err := getDuration("SHUTDOWN_TIMEOUT", 20*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	tlsCertFile, tlsKeyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if (tlsCertFile == "") != (tlsKeyFile == "") {
+		return Config{}, errors.New("TLS_CERT_FILE e TLS_KEY_FILE devem ser definidas juntas")
+	}
+	trustedProxies := getList("TRUSTED_PROXIES")
+	for _, p := range trustedProxies {
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			return Config{}, fmt.Errorf("TRUSTED_PROXIES: %q não é um IP nem um CIDR", p)
+		}
+	}
+
+	// Com uma chave privada configurada, o JWT_SECRET passa a ser opcional
+	// (tokens HS256 antigos só continuam aceitos com JWT_ACCEPT_HS256=true, na migração)
 	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
+	jwtSigningKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE")
+	if jwtSecret == "" && jwtSigningKeyFile == "" {
 		return Config{}, errors.New("JWT_SECRET não está definida")
 	}
+	jwtAcceptHS256, err := getBool("JWT_ACCEPT_HS256", false)
+	if err != nil {
+		return Config{}, err
+	}
+	if jwtAcceptHS256 && (jwtSecret == "" || jwtSigningKeyFile == "") {
+		return Config{}, errors.New("JWT_ACCEPT_HS256 só vale com JWT_SIGNING_KEY_FILE e JWT_SECRET definidas")
+	}
+
+	accessTokenTTL, err := getDuration("ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	refreshTokenTTL, err := getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	oidcIssuer := os.Getenv("OIDC_ISSUER")
+	if oidcIssuer != "" && (os.Getenv("OIDC_CLIENT_ID") == "" || os.Getenv("OIDC_REDIRECT_URL") == "") {
+		return Config{}, errors.New("OIDC_CLIENT_ID e OIDC_REDIRECT_URL são obrigatórias quando OIDC_ISSUER está definida")
+	}
+	oidcScopes := getList("OIDC_SCOPES")
+	if oidcScopes == nil {
+		oidcScopes = []string{"email", "profile"}
+	}
+
+	mfaIssuer := os.Getenv("MFA_ISSUER")
+	if mfaIssuer == "" {
+		mfaIssuer = "EcoPonto"
+	}
+	mfaChallengeTTL, err := getDuration("MFA_CHALLENGE_TTL", 5*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	mfaCadastroTTL, err := getDuration("MFA_CADASTRO_TTL", 72*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	loginJanela, err := getDuration("LOGIN_JANELA", 15*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	loginTentativasLivres, err := getInt("LOGIN_TENTATIVAS_LIVRES", 3)
+	if err != nil {
+		return Config{}, err
+	}
+	loginAtrasoBase, err := getDuration("LOGIN_ATRASO_BASE", 2*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	loginMaxFalhasConta, err := getInt("LOGIN_MAX_FALHAS_CONTA", 10)
+	if err != nil {
+		return Config{}, err
+	}
+	if loginMaxFalhasConta < 1 {
+		return Config{}, errors.New("LOGIN_MAX_FALHAS_CONTA deve ser ao menos 1")
+	}
+	loginMaxFalhasIP, err := getInt("LOGIN_MAX_FALHAS_IP", 50)
+	if err != nil {
+		return Config{}, err
+	}
+	if loginMaxFalhasIP < 1 {
+		return Config{}, errors.New("LOGIN_MAX_FALHAS_IP deve ser ao menos 1")
+	}
+	loginBloqueio, err := getDuration("LOGIN_BLOQUEIO", 15*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	loginRetencao, err := getDuration("LOGIN_RETENCAO", 90*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	if loginRetencao < max(loginJanela, loginBloqueio) {
+		return Config{}, errors.New("LOGIN_RETENCAO deve ser maior que LOGIN_JANELA e LOGIN_BLOQUEIO")
+	}
+
+	passwordResetURL := os.Getenv("PASSWORD_RESET_URL")
+	if passwordResetURL == "" {
+		passwordResetURL = "http://localhost:5173/redefinir-senha"
+	}
+	passwordResetTTL, err := getDuration("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	passwordResetPorIP, err := getInt("PASSWORD_RESET_POR_IP", 5)
+	if err != nil {
+		return Config{}, err
+	}
+	if passwordResetPorIP < 1 {
+		return Config{}, errors.New("PASSWORD_RESET_POR_IP deve ser ao menos 1")
+	}
+	passwordResetIntervalo, err := getDuration("PASSWORD_RESET_INTERVALO", 5*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "EcoPonto <nao-responda@localhost>"
+	}
+	smtpPort, err := getInt("SMTP_PORT", 587)
+	if err != nil {
+		return Config{}, err
+	}
+	smtpTimeout, err := getDuration("SMTP_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	mailDev, err := getBool("MAIL_DEV", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	defaultTenant := os.Getenv("DEFAULT_TENANT")
+	if defaultTenant == "" {
+		defaultTenant = "default"
+	}
+
+	geocoderProvider := os.Getenv("GEOCODER_PROVIDER")
+	if geocoderProvider == "" {
+		geocoderProvider = "nominatim"
+	}
+
+	cacheTTL, err := getDuration("GEOCODER_CACHE_TTL", 30*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	cacheNegativeTTL, err := getDuration("GEOCODER_CACHE_NEGATIVE_TTL", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	geocoderTimeout, err := getDuration("GEOCODER_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	geocoderRateLimit, err := getFloat("GEOCODER_RATE_LIMIT", 1)
+	if err != nil {
+		return Config{}, err
+	}
+	if geocoderRateLimit == 0 {
+		return Config{}, errors.New("GEOCODER_RATE_LIMIT deve ser maior que 0")
+	}
+	geocoderMaxRetries, err := getInt("GEOCODER_MAX_RETRIES", 3)
+	if err != nil {
+		return Config{}, err
+	}
+	geocoderBreakerThreshold, err := getInt("GEOCODER_BREAKER_THRESHOLD", 5)
+	if err != nil {
+		return Config{}, err
+	}
+	geocoderBreakerCooldown, err := getDuration("GEOCODER_BREAKER_COOLDOWN", 30*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	jobWorkers, err := getInt("JOB_WORKERS", 2)
+	if err != nil {
+		return Config{}, err
+	}
+	jobPollInterval, err := getDuration("JOB_POLL_INTERVAL", 2*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	jobMaxTentativas, err := getInt("JOB_MAX_TENTATIVAS", 5)
+	if err != nil {
+		return Config{}, err
+	}
+
+	logLevel := slog.LevelInfo
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		if logLevel, err = logging.ParseLevel(v); err != nil {
+			return Config{}, fmt.Errorf("LOG_LEVEL inválido (use debug, info, warn ou error): %q", v)
+		}
+	}
+
+	tracingServiceName := os.Getenv("OTEL_SERVICE_NAME")
+	if tracingServiceName == "" {
+		tracingServiceName = "ecoponto-api"
+	}
+	tracingSampleRatio, err := getFloat("OTEL_TRACES_SAMPLE_RATIO", 1)
+	if err != nil {
+		return Config{}, err
+	}
+	if tracingSampleRatio < 0 || tracingSampleRatio > 1 {
+		return Config{}, fmt.Errorf("OTEL_TRACES_SAMPLE_RATIO deve estar entre 0 e 1: %v", tracingSampleRatio)
+	}
+
+	healthCheckTimeout, err := getDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	healthGeocoderCache, err := getDuration("HEALTH_GEOCODER_CACHE", 30*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	healthGeocoderCritico, err := getBool("HEALTH_GEOCODER_CRITICO", false)
+	if err != nil {
+		return Config{}, err
+	}
 
 	config := Config{
 		APIPort:     apiPort,
 		DatabaseURL: dbURL,
 		JWTSecret:   jwtSecret,
+
+		HTTPReadTimeout:       httpReadTimeout,
+		HTTPReadHeaderTimeout: httpReadHeaderTimeout,
+		HTTPWriteTimeout:      httpWriteTimeout,
+		HTTPIdleTimeout:       httpIdleTimeout,
+		HTTPMaxHeaderBytes:    httpMaxHeaderBytes,
+		ShutdownTimeout:       shutdownTimeout,
+		TLSCertFile:           tlsCertFile,
+		TLSKeyFile:            tlsKeyFile,
+		TrustedProxies:        trustedProxies,
+
+		JWTSigningKeyFile: jwtSigningKeyFile,
+		JWTVerifyKeyFiles: getList("JWT_VERIFY_KEY_FILES"),
+		JWTAcceptHS256:    jwtAcceptHS256,
+		DefaultTenant:     defaultTenant,
+
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+
+		OIDCIssuer:       oidcIssuer,
+		OIDCClientID:     os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		OIDCScopes:       oidcScopes,
+		OIDCSuccessURL:   os.Getenv("OIDC_SUCCESS_URL"),
+
+		MFAIssuer:        mfaIssuer,
+		MFARequiredRoles: getList("MFA_REQUIRED_ROLES"),
+		MFAChallengeTTL:  mfaChallengeTTL,
+		MFACadastroTTL:   mfaCadastroTTL,
+
+		LoginJanela:           loginJanela,
+		LoginTentativasLivres: loginTentativasLivres,
+		LoginAtrasoBase:       loginAtrasoBase,
+		LoginMaxFalhasConta:   loginMaxFalhasConta,
+		LoginMaxFalhasIP:      loginMaxFalhasIP,
+		LoginBloqueio:         loginBloqueio,
+		LoginRetencao:         loginRetencao,
+
+		PasswordResetURL:       passwordResetURL,
+		PasswordResetTTL:       passwordResetTTL,
+		PasswordResetPorIP:     passwordResetPorIP,
+		PasswordResetIntervalo: passwordResetIntervalo,
+
+		MailDriver:   os.Getenv("MAIL_DRIVER"),
+		MailDev:      mailDev,
+		MailFrom:     mailFrom,
+		MailDir:      os.Getenv("MAIL_DIR"),
+		SMTPHost:     os.Getenv("SMTP_HOST"),
+		SMTPPort:     smtpPort,
+		SMTPUser:     os.Getenv("SMTP_USER"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPTimeout:  smtpTimeout,
+
+		GeocoderProvider:   geocoderProvider,
+		GeocoderURL:        os.Getenv("GEOCODER_URL"),
+		GeocoderUserAgent:  os.Getenv("GEOCODER_USER_AGENT"),
+		GeocoderStaticFile: os.Getenv("GEOCODER_STATIC_FILE"),
+
+		GeocoderCacheTTL:         cacheTTL,
+		GeocoderCacheNegativeTTL: cacheNegativeTTL,
+
+		GeocoderTimeout:          geocoderTimeout,
+		GeocoderRateLimit:        geocoderRateLimit,
+		GeocoderMaxRetries:       geocoderMaxRetries,
+		GeocoderBreakerThreshold: geocoderBreakerThreshold,
+		GeocoderBreakerCooldown:  geocoderBreakerCooldown,
+
+		JobWorkers:       jobWorkers,
+		JobPollInterval:  jobPollInterval,
+		JobMaxTentativas: jobMaxTentativas,
+
+		MetricsToken: os.Getenv("METRICS_TOKEN"),
+
+		HealthCheckTimeout:    healthCheckTimeout,
+		HealthGeocoderCache:   healthGeocoderCache,
+		HealthGeocoderCritico: healthGeocoderCritico,
+
+		LogLevel: logLevel,
+
+		OTLPEndpoint:       os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		TracingServiceName: tracingServiceName,
+		TracingSampleRatio: tracingSampleRatio,
 	}
 
 	return config, nil
 }
+
+// getDuration lê uma duração (ex.: "24h", "90s") da variável de ambiente,
+// usando o valor padrão se ela não estiver definida
+func getDuration(key string, def time.Duration) (time.Duration, error) {
+	str := os.Getenv(key)
+	if str == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s inválida: %q", key, str)
+	}
+	return d, nil
+}
+
+// getInt lê um inteiro não negativo da variável de ambiente,
+// usando o valor padrão se ela não estiver definida
+func getInt(key string, def int) (int, error) {
+	str := os.Getenv(key)
+	if str == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s inválida: %q", key, str)
+	}
+	return n, nil
+}
+
+// getFloat lê um número não negativo da variável de ambiente,
+// usando o valor padrão se ela não estiver definida
+func getFloat(key string, def float64) (float64, error) {
+	str := os.Getenv(key)
+	if str == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+		return 0, fmt.Errorf("%s inválida: %q", key, str)
+	}
+	return f, nil
+}
+
+// getBool lê um booleano (true/false, 1/0) da variável de ambiente,
+// usando o valor padrão se ela não estiver definida
+func getBool(key string, def bool) (bool, error) {
+	str := os.Getenv(key)
+	if str == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(str)
+	if err != nil {
+		return false, fmt.Errorf("%s inválida: %q", key, str)
+	}
+	return b, nil
+}
+
+// getList lê uma lista separada por vírgulas da variável de ambiente,
+// ignorando itens vazios
+func getList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -1,7 +1,8 @@
 package database
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -11,14 +12,16 @@ import (
 func Connect(databaseURL string) *sqlx.DB {
 	db, err := sqlx.Connect("postgres", databaseURL)
 	if err != nil {
-		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+		slog.Error("Erro ao conectar ao banco de dados", "erro", err)
+		os.Exit(1)
 	}
 
 	// Testa a conexão
 	if err = db.Ping(); err != nil {
-		log.Fatalf("Erro ao 'pingar' o banco de dados: %v", err)
+		slog.Error("Erro ao 'pingar' o banco de dados", "erro", err)
+		os.Exit(1)
 	}
 
-	log.Println("Conexão com o banco de dados estabelecida com sucesso.")
+	slog.Info("Conexão com o banco de dados estabelecida com sucesso")
 	return db
 }
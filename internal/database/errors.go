@@ -0,0 +1,29 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Códigos de erro do PostgreSQL usados pela aplicação
+const (
+	codeForeignKeyViolation = "23503"
+	codeUniqueViolation     = "23505"
+)
+
+// IsUniqueViolation indica se o erro foi causado por uma restrição UNIQUE
+func IsUniqueViolation(err error) bool {
+	return hasCode(err, codeUniqueViolation)
+}
+
+// IsForeignKeyViolation indica se o erro foi causado por uma chave estrangeira
+// (registro referenciado inexistente ou ainda em uso)
+func IsForeignKeyViolation(err error) bool {
+	return hasCode(err, codeForeignKeyViolation)
+}
+
+func hasCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Migrations são os arquivos de migration embutidos no binário, para a API
+// saber qual é a versão mais recente do esquema sem depender do disco
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// LatestMigration retorna o número da migration mais recente embutida no binário
+func LatestMigration() (uint64, error) {
+	entries, err := fs.ReadDir(Migrations, "migrations")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint64
+	for _, e := range entries {
+		// Formato do golang-migrate: 000012_nome.up.sql
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok || !strings.HasSuffix(e.Name(), ".up.sql") {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration com nome inválido: %s", e.Name())
+		}
+		latest = max(latest, v)
+	}
+	return latest, nil
+}
+
+// MigrationStatus lê a versão aplicada no banco pela CLI do golang-migrate
+// (tabela schema_migrations). dirty indica uma migration que falhou no meio.
+func MigrationStatus(ctx context.Context, db *sqlx.DB) (version uint64, dirty bool, err error) {
+	var st struct {
+		Version int64 `db:"version"`
+		Dirty   bool  `db:"dirty"`
+	}
+	err = db.GetContext(ctx, &st, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return uint64(st.Version), st.Dirty, nil
+}
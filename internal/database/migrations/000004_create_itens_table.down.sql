@@ -0,0 +1,5 @@
+-- 1. Remove o índice
+DROP INDEX IF EXISTS idx_itens_tipo_residuo;
+
+-- 2. Remove a tabela
+DROP TABLE IF EXISTS itens;
@@ -0,0 +1,43 @@
+-- Habilita a extensão unaccent para buscas sem acentuação
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- 1. Cria o catálogo de itens (o que o cidadão quer descartar)
+CREATE TABLE IF NOT EXISTS itens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  nome VARCHAR(255) NOT NULL UNIQUE,
+  sinonimos TEXT[] NOT NULL DEFAULT '{}',
+  tipo_residuo VARCHAR(100) NOT NULL,
+  instrucoes TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Cria o índice para filtrar itens por tipo de resíduo
+CREATE INDEX IF NOT EXISTS idx_itens_tipo_residuo ON itens (tipo_residuo);
+
+-- 3. Popula o catálogo com os itens mais buscados
+INSERT INTO itens (nome, sinonimos, tipo_residuo, instrucoes) VALUES
+  ('Pilha', '{"pilhas", "bateria", "baterias", "bateria de celular"}', 'Pilhas e Baterias',
+   'Guarde em um recipiente plástico seco, sem misturar com o lixo comum. Isole os polos de baterias de lítio com fita adesiva.'),
+  ('Óleo de cozinha', '{"óleo usado", "óleo de fritura", "gordura"}', 'Óleo de Cozinha',
+   'Espere esfriar, coe e armazene em garrafa PET bem fechada. Nunca descarte na pia ou no ralo.'),
+  ('Sofá', '{"estofado", "poltrona", "colchão", "móveis"}', 'Volumosos',
+   'Leve inteiro ao ecoponto ou agende a coleta de volumosos da prefeitura. Não deixe na calçada.'),
+  ('Celular', '{"smartphone", "telefone", "tablet", "carregador"}', 'Eletrônicos',
+   'Apague seus dados pessoais e retire o chip e o cartão de memória antes de entregar.'),
+  ('Computador', '{"notebook", "monitor", "teclado", "impressora"}', 'Eletrônicos',
+   'Entregue o equipamento completo, sem desmontar. Apague seus dados pessoais antes do descarte.'),
+  ('Lâmpada fluorescente', '{"lâmpada", "lâmpadas", "lâmpada fria"}', 'Lâmpadas',
+   'Embale na caixa original ou em jornal para evitar que quebre. Lâmpadas quebradas liberam mercúrio.'),
+  ('Pneu', '{"pneus", "câmara de ar"}', 'Pneus',
+   'Entregue sem aro e sem água acumulada, para evitar a proliferação do mosquito da dengue.'),
+  ('Entulho', '{"restos de obra", "tijolo", "cerâmica", "azulejo", "concreto"}', 'Entulho',
+   'Separe de madeira, plástico e outros materiais. Respeite o limite de volume aceito pelo ecoponto.'),
+  ('Galhos', '{"poda", "folhas", "restos de jardim", "grama"}', 'Podas',
+   'Amarre os galhos em feixes de até 1,5 metro para facilitar o transporte.'),
+  ('Garrafa PET', '{"garrafa plástica", "plástico", "embalagem plástica"}', 'Recicláveis',
+   'Esvazie, enxágue e amasse a garrafa para reduzir o volume.'),
+  ('Papelão', '{"caixa de papelão", "papel", "jornal", "revista"}', 'Recicláveis',
+   'Desmonte as caixas e mantenha o material seco.'),
+  ('Vidro', '{"garrafa de vidro", "pote de vidro", "vidros"}', 'Recicláveis',
+   'Enxágue e entregue sem tampa. Embrulhe vidros quebrados em jornal para proteger os coletores.')
+ON CONFLICT (nome) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Remove as colunas
+-- 000005_add_ecoponto_contato.down.sql
+ALTER TABLE ecopontos
+DROP COLUMN telefone,
+DROP COLUMN whatsapp,
+DROP COLUMN website,
+DROP COLUMN email,
+DROP COLUMN acessivel_cadeirante,
+DROP COLUMN estacionamento,
+DROP COLUMN drive_thru;
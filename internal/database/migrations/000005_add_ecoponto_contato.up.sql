@@ -0,0 +1,10 @@
+-- Adiciona as colunas de contato e acessibilidade dos ecopontos
+-- 000005_add_ecoponto_contato.up.sql
+ALTER TABLE ecopontos
+ADD COLUMN telefone VARCHAR(20) NULL,
+ADD COLUMN whatsapp VARCHAR(20) NULL,
+ADD COLUMN website VARCHAR(255) NULL,
+ADD COLUMN email VARCHAR(255) NULL,
+ADD COLUMN acessivel_cadeirante BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN estacionamento BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN drive_thru BOOLEAN NOT NULL DEFAULT FALSE;
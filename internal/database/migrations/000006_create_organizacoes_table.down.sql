@@ -0,0 +1,6 @@
+-- 1. Remove o vínculo dos ecopontos
+DROP INDEX IF EXISTS idx_ecopontos_organizacao_id;
+ALTER TABLE ecopontos DROP COLUMN organizacao_id;
+
+-- 2. Remove a tabela
+DROP TABLE IF EXISTS organizacoes;
@@ -0,0 +1,17 @@
+-- 1. Cria a tabela de organizações que operam os ecopontos
+CREATE TABLE IF NOT EXISTS organizacoes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  nome VARCHAR(255) NOT NULL,
+  cnpj CHAR(14) NOT NULL UNIQUE,
+  tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('prefeitura', 'cooperativa', 'empresa')),
+  email VARCHAR(255) NULL,
+  telefone VARCHAR(20) NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Vincula cada ecoponto (opcionalmente) à organização que o opera
+ALTER TABLE ecopontos
+ADD COLUMN organizacao_id UUID NULL REFERENCES organizacoes (id) ON DELETE RESTRICT;
+
+-- 3. Cria o índice para filtrar ecopontos por operador
+CREATE INDEX IF NOT EXISTS idx_ecopontos_organizacao_id ON ecopontos (organizacao_id);
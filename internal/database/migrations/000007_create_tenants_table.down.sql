@@ -0,0 +1,24 @@
+-- 1. Remove os índices
+DROP INDEX IF EXISTS idx_organizacoes_tenant_id;
+DROP INDEX IF EXISTS idx_ecopontos_tenant_id;
+
+-- 2. Restaura a chave estrangeira simples entre ecopontos e organizações
+ALTER TABLE ecopontos DROP CONSTRAINT IF EXISTS ecopontos_organizacao_fkey;
+ALTER TABLE organizacoes DROP CONSTRAINT IF EXISTS organizacoes_tenant_id_id_key;
+ALTER TABLE ecopontos ADD CONSTRAINT ecopontos_organizacao_id_fkey
+  FOREIGN KEY (organizacao_id) REFERENCES organizacoes (id) ON DELETE RESTRICT;
+
+-- 3. Restaura a unicidade global de e-mail e CNPJ
+ALTER TABLE organizacoes DROP CONSTRAINT IF EXISTS organizacoes_tenant_cnpj_key;
+ALTER TABLE organizacoes ADD CONSTRAINT organizacoes_cnpj_key UNIQUE (cnpj);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+-- 4. Remove o vínculo com os municípios
+ALTER TABLE organizacoes DROP COLUMN tenant_id;
+ALTER TABLE users DROP COLUMN tenant_id;
+ALTER TABLE ecopontos DROP COLUMN tenant_id;
+
+-- 5. Remove a tabela
+DROP TABLE IF EXISTS tenants;
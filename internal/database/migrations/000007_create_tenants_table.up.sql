@@ -0,0 +1,45 @@
+-- 1. Cria a tabela de municípios (tenants) atendidos pela instalação
+CREATE TABLE IF NOT EXISTS tenants (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  slug VARCHAR(63) NOT NULL UNIQUE,
+  nome VARCHAR(255) NOT NULL,
+  host VARCHAR(255) NULL UNIQUE,
+  cidade VARCHAR(100) NULL,
+  estado CHAR(2) NULL,
+  raio_padrao INTEGER NOT NULL DEFAULT 5000,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Cria o município padrão, que recebe todos os dados já existentes
+INSERT INTO tenants (slug, nome) VALUES ('default', 'Município padrão')
+ON CONFLICT (slug) DO NOTHING;
+
+-- 3. Vincula ecopontos, usuários e organizações a um município
+ALTER TABLE ecopontos ADD COLUMN tenant_id UUID NULL REFERENCES tenants (id);
+ALTER TABLE users ADD COLUMN tenant_id UUID NULL REFERENCES tenants (id);
+ALTER TABLE organizacoes ADD COLUMN tenant_id UUID NULL REFERENCES tenants (id);
+
+UPDATE ecopontos SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+UPDATE users SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+UPDATE organizacoes SET tenant_id = (SELECT id FROM tenants WHERE slug = 'default');
+
+ALTER TABLE ecopontos ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE users ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE organizacoes ALTER COLUMN tenant_id SET NOT NULL;
+
+-- 4. E-mail e CNPJ passam a ser únicos por município
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenant_id, email);
+
+ALTER TABLE organizacoes DROP CONSTRAINT IF EXISTS organizacoes_cnpj_key;
+ALTER TABLE organizacoes ADD CONSTRAINT organizacoes_tenant_cnpj_key UNIQUE (tenant_id, cnpj);
+
+-- 5. Um ecoponto só pode ser operado por uma organização do mesmo município
+ALTER TABLE organizacoes ADD CONSTRAINT organizacoes_tenant_id_id_key UNIQUE (tenant_id, id);
+ALTER TABLE ecopontos DROP CONSTRAINT IF EXISTS ecopontos_organizacao_id_fkey;
+ALTER TABLE ecopontos ADD CONSTRAINT ecopontos_organizacao_fkey
+  FOREIGN KEY (tenant_id, organizacao_id) REFERENCES organizacoes (tenant_id, id) ON DELETE RESTRICT;
+
+-- 6. Cria os índices para filtrar por município
+CREATE INDEX IF NOT EXISTS idx_ecopontos_tenant_id ON ecopontos (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_organizacoes_tenant_id ON organizacoes (tenant_id);
@@ -0,0 +1,5 @@
+-- 1. Remove o índice
+DROP INDEX IF EXISTS idx_geocoding_cache_expires_at;
+
+-- 2. Remove a tabela
+DROP TABLE IF EXISTS geocoding_cache;
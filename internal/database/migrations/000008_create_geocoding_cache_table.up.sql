@@ -0,0 +1,16 @@
+-- 1. Cria o cache de geocoding (endereço normalizado -> coordenadas)
+-- Guarda também os endereços não encontrados (cache negativo)
+CREATE TABLE IF NOT EXISTS geocoding_cache (
+  endereco_normalizado TEXT PRIMARY KEY,
+  endereco TEXT NOT NULL,
+  provider VARCHAR(50) NOT NULL,
+  encontrado BOOLEAN NOT NULL,
+  latitude DOUBLE PRECISION NULL,
+  longitude DOUBLE PRECISION NULL,
+  confianca DOUBLE PRECISION NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Cria o índice para a limpeza das entradas expiradas
+CREATE INDEX IF NOT EXISTS idx_geocoding_cache_expires_at ON geocoding_cache (expires_at);
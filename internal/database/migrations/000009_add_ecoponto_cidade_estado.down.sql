@@ -0,0 +1,5 @@
+-- Remove as colunas
+-- 000009_add_ecoponto_cidade_estado.down.sql
+ALTER TABLE ecopontos
+DROP COLUMN cidade,
+DROP COLUMN estado;
@@ -0,0 +1,6 @@
+-- Adiciona cidade e estado aos ecopontos, usados para refazer o geocoding
+-- quando o endereço muda
+-- 000009_add_ecoponto_cidade_estado.up.sql
+ALTER TABLE ecopontos
+ADD COLUMN cidade VARCHAR(100) NULL,
+ADD COLUMN estado VARCHAR(50) NULL;
@@ -0,0 +1,7 @@
+-- 1. Remove os índices
+DROP INDEX IF EXISTS idx_job_itens_job_id;
+DROP INDEX IF EXISTS idx_job_itens_pendentes;
+
+-- 2. Remove as tabelas
+DROP TABLE IF EXISTS job_itens;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,33 @@
+-- 1. Cria a tabela de jobs (processamentos em segundo plano, ex.: importação em lote)
+CREATE TABLE IF NOT EXISTS jobs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  tenant_id UUID NOT NULL REFERENCES tenants (id),
+  tipo VARCHAR(50) NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+    CHECK (status IN ('pendente', 'processando', 'concluido')),
+  total INTEGER NOT NULL DEFAULT 0,
+  created_by UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  finished_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- 2. Cria a tabela com cada item a processar (ex.: um ecoponto a geocodificar e inserir)
+CREATE TABLE IF NOT EXISTS job_itens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  job_id UUID NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+  posicao INTEGER NOT NULL,
+  payload JSONB NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+    CHECK (status IN ('pendente', 'processando', 'concluido', 'revisao_manual')),
+  tentativas INTEGER NOT NULL DEFAULT 0,
+  proxima_tentativa_em TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  erro TEXT NULL,
+  ecoponto_id UUID NULL REFERENCES ecopontos (id) ON DELETE SET NULL,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 3. Cria os índices usados pelos workers e pela consulta de status
+CREATE INDEX IF NOT EXISTS idx_job_itens_pendentes ON job_itens (proxima_tentativa_em)
+  WHERE status IN ('pendente', 'processando');
+CREATE INDEX IF NOT EXISTS idx_job_itens_job_id ON job_itens (job_id);
@@ -0,0 +1,7 @@
+-- 1. Remove os índices
+DROP INDEX IF EXISTS idx_refresh_tokens_sessao_id;
+DROP INDEX IF EXISTS idx_sessoes_user_id;
+
+-- 2. Remove as tabelas
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessoes;
@@ -0,0 +1,25 @@
+-- 1. Cria a tabela de sessões (cada login abre uma; os refresh tokens dela formam uma "família")
+CREATE TABLE IF NOT EXISTS sessoes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  tenant_id UUID NOT NULL REFERENCES tenants (id),
+  user_agent TEXT NULL,
+  ip VARCHAR(64) NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  revoked_at TIMESTAMP WITH TIME ZONE NULL,
+  motivo_revogacao VARCHAR(50) NULL
+);
+
+-- 2. Cria a tabela de refresh tokens (guardamos só o hash SHA-256)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  sessao_id UUID NOT NULL REFERENCES sessoes (id) ON DELETE CASCADE,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 3. Cria os índices
+CREATE INDEX IF NOT EXISTS idx_sessoes_user_id ON sessoes (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_sessao_id ON refresh_tokens (sessao_id);
@@ -0,0 +1,2 @@
+-- 1. Remove o papel dos usuários
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,7 @@
+-- 1. Adiciona o papel (role) do usuário; novos usuários só podem consultar
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'viewer'
+    CHECK (role IN ('superadmin', 'editor', 'moderator', 'viewer'));
+
+-- 2. Os usuários existentes tinham acesso total: viram superadmin
+UPDATE users SET role = 'superadmin';
@@ -0,0 +1,4 @@
+-- 1. Remove as colunas de status e de alteração
+ALTER TABLE users
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS ativo;
@@ -0,0 +1,4 @@
+-- 1. Permite desativar usuários sem apagá-los, e registra a última alteração
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS ativo BOOLEAN NOT NULL DEFAULT TRUE,
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
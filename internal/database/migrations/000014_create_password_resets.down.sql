@@ -0,0 +1,5 @@
+-- 1. Remove o índice
+DROP INDEX IF EXISTS idx_password_resets_user_id;
+
+-- 2. Remove a tabela
+DROP TABLE IF EXISTS password_resets;
@@ -0,0 +1,13 @@
+-- 1. Cria a tabela de tokens de redefinição de senha (guardamos só o hash SHA-256)
+CREATE TABLE IF NOT EXISTS password_resets (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE NULL,
+  ip VARCHAR(64) NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Cria o índice por usuário (invalidação dos tokens anteriores)
+CREATE INDEX IF NOT EXISTS idx_password_resets_user_id ON password_resets (user_id);
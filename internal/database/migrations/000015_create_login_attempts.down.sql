@@ -0,0 +1,6 @@
+-- 1. Remove os índices
+DROP INDEX IF EXISTS idx_login_attempts_ip;
+DROP INDEX IF EXISTS idx_login_attempts_email;
+
+-- 2. Remove a tabela
+DROP TABLE IF EXISTS login_attempts;
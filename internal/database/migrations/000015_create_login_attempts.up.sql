@@ -0,0 +1,17 @@
+-- 1. Cria a tabela de auditoria das tentativas de login
+-- resultado: sucesso, senha_invalida, usuario_inexistente, desativado, bloqueado
+-- ou desbloqueio (registrado quando um admin desbloqueia a conta)
+CREATE TABLE IF NOT EXISTS login_attempts (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id UUID NOT NULL REFERENCES tenants (id),
+  email VARCHAR(255) NOT NULL,
+  user_id UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+  ip VARCHAR(64) NOT NULL,
+  user_agent TEXT NULL,
+  resultado VARCHAR(30) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 2. Cria os índices usados para calcular os bloqueios (por conta e por IP)
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts (tenant_id, email, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip, created_at);
@@ -0,0 +1,5 @@
+-- 1. Remove o índice
+DROP INDEX IF EXISTS idx_api_keys_tenant_id;
+
+-- 2. Remove a tabela
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,18 @@
+-- 1. Cria a tabela de chaves de API (clientes máquina-a-máquina)
+-- Guardamos só o hash SHA-256 da chave; o prefixo identifica a chave nas listagens
+CREATE TABLE IF NOT EXISTS api_keys (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  tenant_id UUID NOT NULL REFERENCES tenants (id),
+  nome VARCHAR(255) NOT NULL,
+  prefixo VARCHAR(20) NOT NULL UNIQUE,
+  key_hash CHAR(64) NOT NULL UNIQUE,
+  scopes TEXT[] NOT NULL DEFAULT '{}',
+  expires_at TIMESTAMP WITH TIME ZONE NULL,
+  last_used_at TIMESTAMP WITH TIME ZONE NULL,
+  created_by UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  revoked_at TIMESTAMP WITH TIME ZONE NULL
+);
+
+-- 2. Cria o índice da listagem por município
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys (tenant_id);
@@ -0,0 +1,7 @@
+-- 1. Remove os índices
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+DROP INDEX IF EXISTS idx_oidc_states_expires_at;
+
+-- 2. Remove as tabelas
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS oidc_states;
@@ -0,0 +1,26 @@
+-- 1. Cria a tabela dos logins OIDC em andamento (state, nonce e code_verifier do PKCE)
+-- Guardamos o hash do state; cada linha é usada uma única vez no callback
+CREATE TABLE IF NOT EXISTS oidc_states (
+  state_hash CHAR(64) PRIMARY KEY,
+  tenant_id UUID NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+  nonce VARCHAR(64) NOT NULL,
+  code_verifier VARCHAR(128) NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Cria a tabela que liga a identidade externa (issuer + subject) ao usuário
+CREATE TABLE IF NOT EXISTS user_identities (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  issuer TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  email VARCHAR(255) NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+  last_login_at TIMESTAMP WITH TIME ZONE NULL,
+  UNIQUE (issuer, subject)
+);
+
+-- 3. Cria os índices
+CREATE INDEX IF NOT EXISTS idx_oidc_states_expires_at ON oidc_states (expires_at);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
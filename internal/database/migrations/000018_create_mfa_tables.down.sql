@@ -0,0 +1,7 @@
+-- 1. Remove os índices
+DROP INDEX IF EXISTS idx_recovery_codes_user_id;
+
+-- 2. Remove as tabelas
+DROP TABLE IF EXISTS mfa_challenges;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS user_totp;
@@ -0,0 +1,34 @@
+-- 1. Cria a tabela do segundo fator (TOTP, RFC 6238) de cada usuário
+-- confirmed_at nulo = cadastro iniciado mas ainda não confirmado com um código
+-- ultimo_passo guarda o último intervalo de 30s aceito, para o mesmo código não valer duas vezes
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+  secret VARCHAR(64) NOT NULL,
+  confirmed_at TIMESTAMP WITH TIME ZONE NULL,
+  ultimo_passo BIGINT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Cria a tabela dos códigos de recuperação (uso único; guardamos só o hash SHA-256)
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  code_hash CHAR(64) NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+-- 3. Cria a tabela dos desafios de login: emitidos após a senha correta,
+-- trocados pelos tokens em POST /api/auth/login/2fa
+CREATE TABLE IF NOT EXISTS mfa_challenges (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  tenant_id UUID NOT NULL REFERENCES tenants (id),
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  tentativas INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
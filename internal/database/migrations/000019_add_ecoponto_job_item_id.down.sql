@@ -0,0 +1,3 @@
+-- 1. Remove a ligação com o item de importação
+ALTER TABLE ecopontos
+  DROP COLUMN IF EXISTS job_item_id;
@@ -0,0 +1,5 @@
+-- 1. Liga o ecoponto ao item de importação que o criou.
+-- O UNIQUE torna a importação idempotente: se o worker inserir o ecoponto e cair
+-- antes de concluir o item, a nova tentativa reaproveita o ecoponto em vez de duplicá-lo
+ALTER TABLE ecopontos
+  ADD COLUMN IF NOT EXISTS job_item_id UUID NULL UNIQUE REFERENCES job_itens (id) ON DELETE SET NULL;
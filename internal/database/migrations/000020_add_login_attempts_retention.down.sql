@@ -0,0 +1,3 @@
+-- 1. Remove o comentário e o índice da limpeza
+COMMENT ON COLUMN login_attempts.resultado IS NULL;
+DROP INDEX IF EXISTS idx_login_attempts_created_at;
@@ -0,0 +1,7 @@
+-- 1. Índice usado pela limpeza periódica das tentativas antigas (LOGIN_RETENCAO)
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created_at ON login_attempts (created_at);
+
+-- 2. resultado passa a aceitar também em_andamento: a tentativa é reservada antes de
+-- conferir a senha e conta como falha até ser concluída
+COMMENT ON COLUMN login_attempts.resultado IS
+  'sucesso, senha_invalida, usuario_inexistente, desativado, bloqueado, desbloqueio ou em_andamento';
@@ -0,0 +1,2 @@
+-- 1. Remove a tabela dos códigos de cadastro
+DROP TABLE IF EXISTS mfa_enrollment_codes;
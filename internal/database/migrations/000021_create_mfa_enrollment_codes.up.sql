@@ -0,0 +1,14 @@
+-- 1. Cria a tabela dos códigos de cadastro do 2FA: emitidos por um administrador e
+-- entregues fora da API, são exigidos no primeiro cadastro pelo login
+-- (POST /api/auth/login/2fa/cadastro), para quem tem só a senha não vincular
+-- o próprio aplicativo à conta. Guardamos só o hash SHA-256; um por usuário.
+CREATE TABLE IF NOT EXISTS mfa_enrollment_codes (
+  user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+  code_hash CHAR(64) NOT NULL,
+  created_by UUID NULL REFERENCES users (id) ON DELETE SET NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- 2. Descarta os cadastros pendentes iniciados sem código (o usuário recomeça o cadastro)
+DELETE FROM user_totp WHERE confirmed_at IS NULL;
@@ -0,0 +1,10 @@
+-- 1. Volta à unicidade global (falha se a mesma identidade estiver ligada em dois municípios)
+ALTER TABLE user_identities
+  DROP CONSTRAINT IF EXISTS user_identities_tenant_issuer_subject_key;
+
+ALTER TABLE user_identities
+  ADD CONSTRAINT user_identities_issuer_subject_key UNIQUE (issuer, subject);
+
+-- 2. Remove o município da identidade
+ALTER TABLE user_identities
+  DROP COLUMN IF EXISTS tenant_id;
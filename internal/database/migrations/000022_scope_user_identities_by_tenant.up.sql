@@ -0,0 +1,18 @@
+-- 1. A identidade externa passa a ser ligada por município: a mesma pessoa do
+-- provedor pode ter usuários em municípios diferentes
+ALTER TABLE user_identities
+  ADD COLUMN IF NOT EXISTS tenant_id UUID NULL REFERENCES tenants (id) ON DELETE CASCADE;
+
+UPDATE user_identities ui SET tenant_id = u.tenant_id
+FROM users u
+WHERE u.id = ui.user_id AND ui.tenant_id IS NULL;
+
+ALTER TABLE user_identities
+  ALTER COLUMN tenant_id SET NOT NULL;
+
+-- 2. Troca a unicidade global (issuer, subject) pela unicidade no município
+ALTER TABLE user_identities
+  DROP CONSTRAINT IF EXISTS user_identities_issuer_subject_key;
+
+ALTER TABLE user_identities
+  ADD CONSTRAINT user_identities_tenant_issuer_subject_key UNIQUE (tenant_id, issuer, subject);
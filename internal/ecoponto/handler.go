@@ -1,24 +1,31 @@
 package ecoponto
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/database"
 	"github.com/ericoliveiras/ecoponto-api/internal/geocoding"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
 	"github.com/gin-gonic/gin"
 )
 
 // Handler gerencia as requisições HTTP relacionadas aos ecopontos
 type Handler struct {
-	repo *Repository
+	repo     *Repository
+	geocoder geocoding.Geocoder
 }
 
 // NewHandler cria uma nova instância do handler
-func NewHandler(repo *Repository) *Handler {
-	return &Handler{repo: repo}
+func NewHandler(repo *Repository, geocoder geocoding.Geocoder) *Handler {
+	return &Handler{repo: repo, geocoder: geocoder}
 }
 
 // CreateEcoponto é o método para o endpoint POST /api/ecopontos
@@ -26,23 +33,22 @@ func (h *Handler) CreateEcoponto(c *gin.Context) {
 	// 1. Faz o "bind" do JSON (que agora pode ter lat/lon)
 	var req CreateEcoPontoRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	var lat, lon float64
-	var err error
 
 	// Verificamos se os ponteiros NÃO SÃO nulos
 	if req.Latitude != nil && req.Longitude != nil {
 
-		log.Println("Recebidas coordenadas manuais do frontend.")
+		slog.DebugContext(c.Request.Context(), "Recebidas coordenadas manuais do frontend")
 		lat = *req.Latitude
 		lon = *req.Longitude
 
 	} else {
 		// CENÁRIO 2: O frontend NÃO enviou. Usar Geocoding (como antes).
-		log.Println("Coordenadas não fornecidas. A acionar geocoding...")
+		slog.DebugContext(c.Request.Context(), "Coordenadas não fornecidas, acionando geocoding")
 
 		// Monta a string de endereço
 		addressString := fmt.Sprintf("%s, %s, %s, %s",
@@ -53,75 +59,137 @@ func (h *Handler) CreateEcoponto(c *gin.Context) {
 		)
 
 		// Chama o Geocoder
-		lat, lon, err = geocoding.GetCoordsFromAddress(addressString)
+		res, err := h.geocoder.Geocode(c.Request.Context(), addressString)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Endereço não encontrado ou inválido"})
+			if errors.Is(err, geocoding.ErrNotFound) {
+				apierror.Respond(c, http.StatusBadRequest, "Endereço não encontrado ou inválido")
+				return
+			}
+			// O provedor falhou: não é culpa do endereço enviado
+			slog.WarnContext(c.Request.Context(), "Erro no geocoding", "erro", err)
+			status, msg := geocoding.ErrorResponse(err)
+			apierror.Respond(c, status, msg)
 			return
 		}
+		lat, lon = res.Latitude, res.Longitude
 	}
 
 	// 3. Chama o repositório
-	novoPonto, err := h.repo.Create(c.Request.Context(), req, lat, lon)
+	novoPonto, err := h.repo.Create(c.Request.Context(), tenant.IDFromContext(c), req, lat, lon)
 	if err != nil {
+		// Se a organização informada não existe
+		if database.IsForeignKeyViolation(err) {
+			apierror.Respond(c, http.StatusBadRequest, "Organização não encontrada")
+			return
+		}
 		// Se der erro no banco
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Internal(c, err)
 		return
 	}
 
-	// 4. Retorna o objeto criado
+	// 4. Se as coordenadas vieram do pin, confere se batem com o endereço digitado
+	if req.Latitude != nil && req.Longitude != nil {
+		novoPonto.Avisos = h.checkAddress(c.Request.Context(), novoPonto)
+	}
+
+	// 5. Retorna o objeto criado
 	c.JSON(http.StatusCreated, novoPonto)
 }
 
 // ListEcopontos é o método para o endpoint GET /api/ecopontos
 func (h *Handler) ListEcopontos(c *gin.Context) {
-	// 1. Ler os parâmetros da query (URL)
+	// 1. Ler e validar os parâmetros de proximidade da query (URL)
+	params, err := ParseListByProximityParams(c)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Filtro opcional por tipo de resíduo
+	params.TipoResiduo = c.Query("tipo") // Pega o ?tipo=...
+
+	// 3. Chamar o repositório
+	pontos, err := h.repo.ListByProximity(c.Request.Context(), tenant.IDFromContext(c), params)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Retornar a lista de pontos
+	c.JSON(http.StatusOK, pontos)
+}
+
+// ParseListByProximityParams lê os parâmetros lat, lon e dist da query.
+// É compartilhado com outros handlers que reutilizam a busca por proximidade.
+func ParseListByProximityParams(c *gin.Context) (ListByProximityParams, error) {
 	latStr := c.Query("lat")
 	lonStr := c.Query("lon")
 	distStr := c.Query("dist")
 
-	tipoStr := c.Query("tipo") // Pega o ?tipo=...
-
-	// 2. Validar parâmetros obrigatórios (lat, lon)
+	// 1. Validar parâmetros obrigatórios (lat, lon)
 	if latStr == "" || lonStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetros 'lat' e 'lon' são obrigatórios"})
-		return
+		return ListByProximityParams{}, errors.New("Parâmetros 'lat' e 'lon' são obrigatórios")
 	}
 
-	// 3. Converter strings para números (float64 e int)
+	// 2. Converter strings para números (float64 e int)
 	lat, err := strconv.ParseFloat(latStr, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'lat' inválido"})
-		return
+		return ListByProximityParams{}, errors.New("Parâmetro 'lat' inválido")
 	}
 	lon, err := strconv.ParseFloat(lonStr, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetro 'lon' inválido"})
-		return
+		return ListByProximityParams{}, errors.New("Parâmetro 'lon' inválido")
 	}
 
-	// 4. Definir uma distância padrão
+	// 3. Definir uma distância padrão (configurável por município)
 	dist, err := strconv.Atoi(distStr)
 	if err != nil || dist <= 0 {
 		dist = 5000 // Default: 5 km
+		if t := tenant.FromContext(c); t != nil && t.RaioPadrao > 0 {
+			dist = t.RaioPadrao
+		}
 	}
 
-	// 5. Montar os parâmetros para o repositório
 	params := ListByProximityParams{
-		Latitude:    lat,
-		Longitude:   lon,
-		Distancia:   dist,
-		TipoResiduo: tipoStr, // Passa o filtro para o repositório
+		Latitude:  lat,
+		Longitude: lon,
+		Distancia: dist,
 	}
 
-	// 6. Chamar o repositório
-	pontos, err := h.repo.ListByProximity(c.Request.Context(), params)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// 4. Filtro opcional por organização operadora (?organizacao=<id>)
+	var filtro struct {
+		OrganizacaoID string `form:"organizacao" binding:"omitempty,uuid"`
+	}
+	if err := c.ShouldBindQuery(&filtro); err != nil {
+		return ListByProximityParams{}, errors.New("Parâmetro 'organizacao' inválido")
 	}
+	params.OrganizacaoID = filtro.OrganizacaoID
 
-	// 7. Retornar a lista de pontos
-	c.JSON(http.StatusOK, pontos)
+	// 5. Filtros opcionais de acessibilidade (?acessivel=true, ?estacionamento=true, ?drive_thru=true)
+	if params.AcessivelCadeirante, err = parseBoolQuery(c, "acessivel"); err != nil {
+		return ListByProximityParams{}, err
+	}
+	if params.Estacionamento, err = parseBoolQuery(c, "estacionamento"); err != nil {
+		return ListByProximityParams{}, err
+	}
+	if params.DriveThru, err = parseBoolQuery(c, "drive_thru"); err != nil {
+		return ListByProximityParams{}, err
+	}
+
+	return params, nil
+}
+
+// parseBoolQuery lê um parâmetro booleano opcional da query (nil se ausente)
+func parseBoolQuery(c *gin.Context, name string) (*bool, error) {
+	str := c.Query(name)
+	if str == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(str)
+	if err != nil {
+		return nil, fmt.Errorf("Parâmetro '%s' inválido", name)
+	}
+	return &v, nil
 }
 
 func (h *Handler) GetEcoponto(c *gin.Context) {
@@ -129,17 +197,17 @@ func (h *Handler) GetEcoponto(c *gin.Context) {
 	id := c.Param("id")
 
 	// 2. Chamar o repositório
-	ponto, err := h.repo.GetByID(c.Request.Context(), id)
+	ponto, err := h.repo.GetByID(c.Request.Context(), tenant.IDFromContext(c), id)
 	if err != nil {
 		// 3. Checar o tipo do erro
 		if err == sql.ErrNoRows {
 			// Se o banco não achou nada, retornamos 404
-			c.JSON(http.StatusNotFound, gin.H{"error": "Ecoponto não encontrado"})
+			apierror.Respond(c, http.StatusNotFound, "Ecoponto não encontrado")
 			return
 		}
 
 		// Para qualquer outro erro do banco
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Internal(c, err)
 		return
 	}
 
@@ -157,65 +225,168 @@ func (h *Handler) UpdateEcoponto(c *gin.Context) {
 
 	// 3. Faz o "bind" do JSON do body para a struct 'req'
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// 4. Lógica Condicional de Coordenadas
 	// Verificamos se o frontend enviou novas coordenadas (pin arrastado)
-	if req.Latitude != nil && req.Longitude != nil {
+	pinMovido := req.Latitude != nil && req.Longitude != nil
+	var geocodificacao string
 
-		log.Println("UPDATE: Recebidas coordenadas manuais do frontend.")
+	if pinMovido {
+
+		slog.DebugContext(c.Request.Context(), "Recebidas coordenadas manuais do frontend", "id", id)
 		// O repositório (com squirrel) saberá lidar com estes campos
 		// não precisamos de 'lat' e 'lon' separadas aqui.
 
-	} else if req.Logradouro != nil || req.Bairro != nil {
-		// CENÁRIO 2: O frontend NÃO enviou coords, mas enviou um NOVO endereço
+	} else if req.EnderecoAlterado() && req.ManterCoordenadas {
+		// CENÁRIO 2: O endereço mudou, mas o admin pediu para manter o pin onde está
+		slog.DebugContext(c.Request.Context(), "Endereço alterado, coordenadas mantidas a pedido do admin", "id", id)
+		geocodificacao = GeocodificacaoIgnorada
+
+	} else if req.EnderecoAlterado() {
+		// CENÁRIO 3: O frontend NÃO enviou coords, mas enviou um NOVO endereço
 		// (Precisamos de fazer geocoding neste novo endereço)
+		slog.DebugContext(c.Request.Context(), "Acionando geocoding para o novo endereço", "id", id)
+
+		res, status, msg := h.geocodeUpdatedAddress(c, id, req)
+		if res == nil {
+			apierror.Respond(c, status, msg)
+			return
+		}
 
-		log.Println("UPDATE: A acionar geocoding para novo endereço.")
+		// As novas coordenadas são gravadas no mesmo UPDATE do endereço
+		req.Latitude = &res.Latitude
+		req.Longitude = &res.Longitude
+		geocodificacao = GeocodificacaoAtualizada
 	}
 
 	// 5. Chama o repositório para atualizar o ecoponto
 	// O 'repo.Update' (com Squirrel) é inteligente e irá
 	// atualizar apenas os campos que não são nulos no 'req'.
-	pontoAtualizado, err := h.repo.Update(c.Request.Context(), id, req)
+	pontoAtualizado, err := h.repo.Update(c.Request.Context(), tenant.IDFromContext(c), id, req)
 	if err != nil {
 		// 6. Checar os tipos de erro
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Ecoponto não encontrado"})
+			apierror.Respond(c, http.StatusNotFound, "Ecoponto não encontrado")
 			return
 		}
 		if err == sql.ErrTxDone {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Latitude e Longitude devem ser enviadas juntas"})
+			apierror.Respond(c, http.StatusBadRequest, "Latitude e Longitude devem ser enviadas juntas")
+			return
+		}
+		if database.IsForeignKeyViolation(err) {
+			apierror.Respond(c, http.StatusBadRequest, "Organização não encontrada")
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Internal(c, err)
 		return
 	}
 
-	// 7. Retorna o objeto atualizado
+	// 7. Se o pin foi movido, confere se ainda bate com o endereço salvo
+	if pinMovido {
+		pontoAtualizado.Avisos = h.checkAddress(c.Request.Context(), pontoAtualizado)
+	}
+	pontoAtualizado.Geocodificacao = geocodificacao
+
+	// 8. Retorna o objeto atualizado
 	c.JSON(http.StatusOK, pontoAtualizado)
 }
 
+// geocodeUpdatedAddress junta o novo endereço do request com o endereço salvo
+// e faz o geocoding do resultado. Em caso de falha, retorna nil com o status
+// HTTP e a mensagem de erro a devolver ao cliente.
+func (h *Handler) geocodeUpdatedAddress(c *gin.Context, id string, req UpdateEcoPontoRequest) (*geocoding.Result, int, string) {
+	ctx := c.Request.Context()
+
+	// 1. Busca o endereço atual
+	atual, err := h.repo.GetByID(ctx, tenant.IDFromContext(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, http.StatusNotFound, "Ecoponto não encontrado"
+		}
+		slog.ErrorContext(ctx, "Erro ao buscar ecoponto", "erro", err, "id", id)
+		return nil, http.StatusInternalServerError, apierror.MensagemInterna
+	}
+
+	// 2. Junta os campos enviados com os salvos (cidade/estado caem para os do município)
+	logradouro := valueOr(req.Logradouro, atual.Logradouro)
+	bairro := valueOr(req.Bairro, atual.Bairro)
+	cidade := valueOr(req.Cidade, valueOr(atual.Cidade, ""))
+	estado := valueOr(req.Estado, valueOr(atual.Estado, ""))
+	if t := tenant.FromContext(c); t != nil {
+		cidade = valueOr(&cidade, valueOr(t.Cidade, ""))
+		estado = valueOr(&estado, valueOr(t.Estado, ""))
+	}
+
+	addressString := strings.Join(nonEmpty(logradouro, bairro, cidade, estado), ", ")
+
+	// 3. Chama o Geocoder
+	res, err := h.geocoder.Geocode(ctx, addressString)
+	if err != nil {
+		if errors.Is(err, geocoding.ErrNotFound) {
+			return nil, http.StatusBadRequest,
+				"Novo endereço não encontrado. Envie latitude e longitude ou manter_coordenadas=true"
+		}
+		slog.WarnContext(ctx, "Erro no geocoding", "erro", err, "id", id)
+		status, msg := geocoding.ErrorResponse(err)
+		return nil, status, msg
+	}
+	return res, 0, ""
+}
+
+// valueOr retorna o valor do ponteiro, ou def se ele for nulo ou vazio
+func valueOr(p *string, def string) string {
+	if p == nil || strings.TrimSpace(*p) == "" {
+		return def
+	}
+	return *p
+}
+
+// nonEmpty retorna apenas os valores não vazios
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// checkAddress faz o geocoding reverso das coordenadas do ponto e retorna
+// avisos se o logradouro/bairro salvos não batem com o local.
+// Falhas no geocoding não impedem o salvamento: apenas não geram avisos.
+func (h *Handler) checkAddress(ctx context.Context, ponto *EcoPonto) []string {
+	addr, err := h.geocoder.Reverse(ctx, ponto.Latitude, ponto.Longitude)
+	if err != nil {
+		if !errors.Is(err, geocoding.ErrNotFound) {
+			slog.WarnContext(ctx, "Erro no geocoding reverso do ecoponto", "erro", err, "id", ponto.ID)
+		}
+		return nil
+	}
+	return addr.Mismatches(ponto.Logradouro, ponto.Bairro)
+}
+
 // DeleteEcoponto é o método para o endpoint DELETE /api/ecopontos/:id
 func (h *Handler) DeleteEcoponto(c *gin.Context) {
 	// 1. Ler o ID do parâmetro da URL
 	id := c.Param("id")
 
 	// 2. Chamar o repositório para apagar
-	err := h.repo.Delete(c.Request.Context(), id)
+	err := h.repo.Delete(c.Request.Context(), tenant.IDFromContext(c), id)
 
 	if err != nil {
 		// 3. Checar se o ID não existia
 		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Ecoponto não encontrado"})
+			apierror.Respond(c, http.StatusNotFound, "Ecoponto não encontrado")
 			return
 		}
 
 		// Outro erro de banco
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Internal(c, err)
 		return
 	}
 
@@ -226,10 +397,10 @@ func (h *Handler) DeleteEcoponto(c *gin.Context) {
 // ListAllEcopontos é o método para o endpoint de admin GET /api/ecopontos/all
 func (h *Handler) ListAllEcopontos(c *gin.Context) {
 	// 1. Chama o repositório
-	pontos, err := h.repo.ListAll(c.Request.Context())
+	pontos, err := h.repo.ListAll(c.Request.Context(), tenant.IDFromContext(c))
 	if err != nil {
 		// 2. Se der erro, retorna 500
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Internal(c, err)
 		return
 	}
 
@@ -0,0 +1,208 @@
+package ecoponto
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ericoliveiras/ecoponto-api/internal/geocoding"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+const testTenantID = "11111111-1111-1111-1111-111111111111"
+
+// aurora é o endereço conhecido pelo geocoder estático dos testes
+var aurora = geocoding.StaticEntry{
+	Endereco:   "Rua da Aurora, Boa Vista, Recife, PE",
+	Latitude:   -8.0631,
+	Longitude:  -34.8811,
+	Logradouro: "Rua da Aurora",
+	Bairro:     "Boa Vista",
+	Cidade:     "Recife",
+	Estado:     "PE",
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter monta as rotas de ecopontos sobre o banco falso e o geocoder estático
+func newTestRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("consultas esperadas não executadas: %v", err)
+		}
+		db.Close()
+	})
+
+	h := NewHandler(NewRepository(sqlx.NewDb(db, "postgres")), geocoding.NewStaticFromEntries([]geocoding.StaticEntry{aurora}))
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		tenant.SetInContext(c, &tenant.Tenant{ID: testTenantID, Slug: tenant.DefaultSlug})
+		c.Next()
+	})
+	r.GET("/api/ecopontos", h.ListEcopontos)
+	r.POST("/api/ecopontos", h.CreateEcoponto)
+	r.PUT("/api/ecopontos/:id", h.UpdateEcoponto)
+	return r, mock
+}
+
+// ecopontoRows é a linha de um ecoponto como o RETURNING a devolve
+func ecopontoRows(logradouro, bairro string, lat, lon float64) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "nome", "tipo_residuo", "logradouro", "bairro", "cidade", "estado", "created_at",
+		"horario_funcionamento", "foto_url",
+		"telefone", "whatsapp", "website", "email",
+		"acessivel_cadeirante", "estacionamento", "drive_thru",
+		"organizacao_id", "longitude", "latitude",
+	}).AddRow(
+		"33333333-3333-3333-3333-333333333333", "Ecoponto Aurora", "vidro", logradouro, bairro, "Recife", "PE", time.Now(),
+		nil, nil,
+		nil, nil, nil, nil,
+		false, false, false,
+		nil, lon, lat,
+	)
+}
+
+func postEcoponto(r http.Handler, body string) *httptest.ResponseRecorder {
+	return sendJSON(r, http.MethodPost, "/api/ecopontos", body)
+}
+
+func sendJSON(r http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// insertArgs são os argumentos do INSERT, conferindo as coordenadas ($5 = lon, $6 = lat)
+func insertArgs(lat, lon float64) []driver.Value {
+	args := make([]driver.Value, 20)
+	for i := range args {
+		args[i] = sqlmock.AnyArg()
+	}
+	args[4], args[5] = lon, lat
+	return args
+}
+
+func TestCreateEcopontoGeocodesAddress(t *testing.T) {
+	r, mock := newTestRouter(t)
+
+	// Sem coordenadas, a posição vem do geocoder
+	mock.ExpectQuery(`INSERT INTO ecopontos`).
+		WithArgs(insertArgs(aurora.Latitude, aurora.Longitude)...).
+		WillReturnRows(ecopontoRows(aurora.Logradouro, aurora.Bairro, aurora.Latitude, aurora.Longitude))
+
+	w := postEcoponto(r, `{"nome": "Ecoponto Aurora", "tipo_residuo": "vidro",
+		"logradouro": "Rua da Aurora", "bairro": "Boa Vista", "cidade": "Recife", "estado": "PE"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+
+	var ponto EcoPonto
+	if err := json.Unmarshal(w.Body.Bytes(), &ponto); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if ponto.Latitude != aurora.Latitude || ponto.Longitude != aurora.Longitude {
+		t.Errorf("coordenadas = (%v, %v), esperado (%v, %v)", ponto.Latitude, ponto.Longitude, aurora.Latitude, aurora.Longitude)
+	}
+	if len(ponto.Avisos) != 0 {
+		t.Errorf("avisos inesperados: %v", ponto.Avisos)
+	}
+}
+
+func TestCreateEcopontoRejectsUnknownAddress(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	// O geocoder não conhece o endereço: nada é gravado
+	w := postEcoponto(r, `{"nome": "Ecoponto", "tipo_residuo": "vidro",
+		"logradouro": "Rua Inexistente", "bairro": "Centro", "cidade": "Recife", "estado": "PE"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+	}
+}
+
+func TestCreateEcopontoWarnsWhenPinDoesNotMatchAddress(t *testing.T) {
+	r, mock := newTestRouter(t)
+
+	// O pin está na Rua da Aurora, mas o endereço digitado é outro
+	mock.ExpectQuery(`INSERT INTO ecopontos`).
+		WithArgs(insertArgs(aurora.Latitude, aurora.Longitude)...).
+		WillReturnRows(ecopontoRows("Rua do Sol", "Santo Antônio", aurora.Latitude, aurora.Longitude))
+
+	w := postEcoponto(r, `{"nome": "Ecoponto Aurora", "tipo_residuo": "vidro",
+		"logradouro": "Rua do Sol", "bairro": "Santo Antônio", "cidade": "Recife", "estado": "PE",
+		"latitude": -8.0631, "longitude": -34.8811}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+
+	var ponto EcoPonto
+	if err := json.Unmarshal(w.Body.Bytes(), &ponto); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if len(ponto.Avisos) != 2 {
+		t.Errorf("esperados avisos de logradouro e bairro, veio %v", ponto.Avisos)
+	}
+}
+
+func TestUpdateEcopontoClearsContactFields(t *testing.T) {
+	r, mock := newTestRouter(t)
+	id := "33333333-3333-3333-3333-333333333333"
+
+	// null e "" apagam o campo; os validadores não recusam o valor vazio
+	mock.ExpectQuery(`UPDATE ecopontos SET website = \$1, telefone = \$2, email = \$3 WHERE id = \$4 AND tenant_id = \$5`).
+		WithArgs("https://ecoponto.recife.pe.gov.br", nil, nil, id, testTenantID).
+		WillReturnRows(ecopontoRows(aurora.Logradouro, aurora.Bairro, aurora.Latitude, aurora.Longitude))
+
+	w := sendJSON(r, http.MethodPut, "/api/ecopontos/"+id,
+		`{"telefone": null, "email": "", "website": "https://ecoponto.recife.pe.gov.br"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+
+	// Valor preenchido continua sendo validado
+	if w := sendJSON(r, http.MethodPut, "/api/ecopontos/"+id, `{"telefone": "81 3355-0000"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+	}
+}
+
+func TestUpdateEcopontoUnlinksOrganizacao(t *testing.T) {
+	r, mock := newTestRouter(t)
+	id := "33333333-3333-3333-3333-333333333333"
+
+	mock.ExpectQuery(`UPDATE ecopontos SET organizacao_id = \$1 WHERE id = \$2 AND tenant_id = \$3`).
+		WithArgs(nil, id, testTenantID).
+		WillReturnRows(ecopontoRows(aurora.Logradouro, aurora.Bairro, aurora.Latitude, aurora.Longitude))
+
+	if w := sendJSON(r, http.MethodPut, "/api/ecopontos/"+id, `{"organizacao_id": null}`); w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+
+	// Um id que não é UUID é recusado antes de chegar ao banco
+	if w := sendJSON(r, http.MethodPut, "/api/ecopontos/"+id, `{"organizacao_id": "cooperativa"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+	}
+}
+
+func TestListEcopontosRejectsInvalidOrganizacaoFilter(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	w := sendJSON(r, http.MethodGet, "/api/ecopontos?lat=-8.06&lon=-34.88&organizacao=cooperativa", "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+	}
+}
@@ -1,11 +1,17 @@
 package ecoponto
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
 
 type EcoPonto struct {
 	ID                   string    `db:"id" json:"id"`
 	Logradouro           string    `db:"logradouro" json:"logradouro"`
 	Bairro               string    `db:"bairro" json:"bairro"`
+	Cidade               *string   `db:"cidade" json:"cidade,omitempty"`
+	Estado               *string   `db:"estado" json:"estado,omitempty"`
 	CreatedAt            time.Time `db:"created_at" json:"created_at"`
 	Nome                 string    `db:"nome" json:"nome"`
 	TipoResiduo          string    `db:"tipo_residuo" json:"tipo_residuo"`
@@ -13,8 +19,36 @@ type EcoPonto struct {
 	Longitude            float64   `db:"longitude" json:"longitude"`
 	HorarioFuncionamento *string   `db:"horario_funcionamento" json:"horario_funcionamento,omitempty"`
 	FotoURL              *string   `db:"foto_url" json:"foto_url,omitempty"`
+	Telefone             *string   `db:"telefone" json:"telefone,omitempty"`
+	WhatsApp             *string   `db:"whatsapp" json:"whatsapp,omitempty"`
+	Website              *string   `db:"website" json:"website,omitempty"`
+	Email                *string   `db:"email" json:"email,omitempty"`
+	AcessivelCadeirante  bool      `db:"acessivel_cadeirante" json:"acessivel_cadeirante"`
+	Estacionamento       bool      `db:"estacionamento" json:"estacionamento"`
+	DriveThru            bool      `db:"drive_thru" json:"drive_thru"`
+	OrganizacaoID        *string   `db:"organizacao_id" json:"organizacao_id,omitempty"`
+
+	// Os campos abaixo não são persistidos: informam o que aconteceu ao salvar.
+	// Avisos lista divergências (ex.: o endereço não bate com o local do pin) e
+	// Geocodificacao diz se as coordenadas foram recalculadas pelo novo endereço.
+	Avisos         []string `db:"-" json:"avisos,omitempty"`
+	Geocodificacao string   `db:"-" json:"geocodificacao,omitempty"`
+}
+
+// ContagemPorTipo é o total de ecopontos de um tipo de resíduo num município (métricas)
+type ContagemPorTipo struct {
+	Municipio   string `db:"municipio"` // Slug do município
+	TipoResiduo string `db:"tipo_residuo"`
+	Total       int    `db:"total"`
 }
 
+// Resultados possíveis de EcoPonto.Geocodificacao no update
+const (
+	GeocodificacaoAtualizada = "atualizada" // Novo endereço geocodificado, coordenadas atualizadas
+	GeocodificacaoIgnorada   = "ignorada"   // Endereço mudou, mas o admin pediu para manter as coordenadas
+)
+
+// Telefones seguem o formato E.164 (ex.: +5511987654321)
 type CreateEcoPontoRequest struct {
 	Nome                 string   `json:"nome" binding:"required"`
 	TipoResiduo          string   `json:"tipo_residuo" binding:"required"`
@@ -26,6 +60,14 @@ type CreateEcoPontoRequest struct {
 	Longitude            *float64 `json:"longitude"`
 	HorarioFuncionamento *string  `json:"horario_funcionamento"`
 	FotoURL              *string  `json:"foto_url"`
+	Telefone             *string  `json:"telefone" binding:"omitempty,e164"`
+	WhatsApp             *string  `json:"whatsapp" binding:"omitempty,e164"`
+	Website              *string  `json:"website" binding:"omitempty,url,max=255"`
+	Email                *string  `json:"email" binding:"omitempty,email,max=255"`
+	AcessivelCadeirante  bool     `json:"acessivel_cadeirante"`
+	Estacionamento       bool     `json:"estacionamento"`
+	DriveThru            bool     `json:"drive_thru"`
+	OrganizacaoID        *string  `json:"organizacao_id" binding:"omitempty,uuid"`
 }
 
 type UpdateEcoPontoRequest struct {
@@ -33,15 +75,85 @@ type UpdateEcoPontoRequest struct {
 	TipoResiduo          *string  `json:"tipo_residuo"`
 	Logradouro           *string  `json:"logradouro"`
 	Bairro               *string  `json:"bairro"`
+	Cidade               *string  `json:"cidade"`
+	Estado               *string  `json:"estado"`
 	Latitude             *float64 `json:"latitude"`
 	Longitude            *float64 `json:"longitude"`
 	HorarioFuncionamento *string  `json:"horario_funcionamento"`
 	FotoURL              *string  `json:"foto_url"`
+	Telefone             *string  `json:"telefone" binding:"omitempty,e164"`
+	WhatsApp             *string  `json:"whatsapp" binding:"omitempty,e164"`
+	Website              *string  `json:"website" binding:"omitempty,url,max=255"`
+	Email                *string  `json:"email" binding:"omitempty,email,max=255"`
+	AcessivelCadeirante  *bool    `json:"acessivel_cadeirante"`
+	Estacionamento       *bool    `json:"estacionamento"`
+	DriveThru            *bool    `json:"drive_thru"`
+	OrganizacaoID        *string  `json:"organizacao_id" binding:"omitempty,uuid"`
+
+	// ManterCoordenadas desliga o geocoding automático quando o endereço muda
+	ManterCoordenadas bool `json:"manter_coordenadas"`
+
+	// limpar são os campos enviados como null ou "", que o update grava como NULL
+	limpar []string
+}
+
+// camposLimpaveis são os campos opcionais que o update apaga quando vêm como null
+// ou "" (o nome no JSON é o mesmo da coluna). Apagar organizacao_id desvincula o
+// ecoponto da organização operadora.
+var camposLimpaveis = []struct {
+	nome  string
+	campo func(r *UpdateEcoPontoRequest) **string
+}{
+	{"telefone", func(r *UpdateEcoPontoRequest) **string { return &r.Telefone }},
+	{"whatsapp", func(r *UpdateEcoPontoRequest) **string { return &r.WhatsApp }},
+	{"website", func(r *UpdateEcoPontoRequest) **string { return &r.Website }},
+	{"email", func(r *UpdateEcoPontoRequest) **string { return &r.Email }},
+	{"organizacao_id", func(r *UpdateEcoPontoRequest) **string { return &r.OrganizacaoID }},
+}
+
+// UnmarshalJSON lê o request e separa os campos a apagar. Eles ficam nulos na
+// struct, então os validadores (omitempty) não os recusam por estarem vazios.
+func (r *UpdateEcoPontoRequest) UnmarshalJSON(data []byte) error {
+	type request UpdateEcoPontoRequest
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	var enviados map[string]json.RawMessage
+	if err := json.Unmarshal(data, &enviados); err != nil {
+		return err
+	}
+
+	*r = UpdateEcoPontoRequest(req)
+	for _, c := range camposLimpaveis {
+		valor, ok := enviados[c.nome]
+		if !ok {
+			continue
+		}
+		campo := c.campo(r)
+		if string(valor) == "null" || strings.TrimSpace(**campo) == "" {
+			*campo = nil
+			r.limpar = append(r.limpar, c.nome)
+		}
+	}
+	return nil
+}
+
+// EnderecoAlterado indica se o request muda algum campo do endereço
+func (r UpdateEcoPontoRequest) EnderecoAlterado() bool {
+	return r.Logradouro != nil || r.Bairro != nil || r.Cidade != nil || r.Estado != nil
 }
 
 type ListByProximityParams struct {
-	Latitude    float64
-	Longitude   float64
-	Distancia   int
-	TipoResiduo string
+	Latitude      float64
+	Longitude     float64
+	Distancia     int
+	TipoResiduo   string
+	OrganizacaoID string
+	Limite        int // 0 = sem limite
+
+	// Filtros opcionais de acessibilidade (nil = não filtra)
+	AcessivelCadeirante *bool
+	Estacionamento      *bool
+	DriveThru           *bool
 }
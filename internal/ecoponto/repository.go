@@ -5,30 +5,61 @@ import (
 	"database/sql"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/ericoliveiras/ecoponto-api/internal/tracing"
 	"github.com/jmoiron/sqlx"
 )
 
+// ecopontoColumns é a lista de colunas lida em todos os SELECT/RETURNING,
+// já com as coordenadas convertidas para latitude/longitude
+const ecopontoColumns = `
+	id, nome, tipo_residuo, logradouro, bairro, cidade, estado, created_at,
+	horario_funcionamento, foto_url,
+	telefone, whatsapp, website, email,
+	acessivel_cadeirante, estacionamento, drive_thru,
+	organizacao_id,
+	ST_X(coordenadas::geometry) AS longitude,
+	ST_Y(coordenadas::geometry) AS latitude`
+
 // Repository gerencia a persistência dos ecopontos
 type Repository struct {
-	db *sqlx.DB
+	db *tracing.DB // Cada consulta vira um span
 }
 
 // NewRepository cria uma nova instância do repositório
 func NewRepository(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: tracing.WrapDB(db)}
 }
 
+// Todos os métodos recebem o ID do município (tenant) e só enxergam os ecopontos
+// dele: um admin de um município nunca lê ou altera linhas de outro.
+
 // Create insere um novo ecoponto no banco de dados
-func (r *Repository) Create(ctx context.Context, req CreateEcoPontoRequest, lat, lon float64) (*EcoPonto, error) {
+func (r *Repository) Create(ctx context.Context, tenantID string, req CreateEcoPontoRequest, lat, lon float64) (*EcoPonto, error) {
+	return r.insert(ctx, tenantID, nil, req, lat, lon)
+}
+
+// CreateFromJobItem insere o ecoponto de um item de importação. É idempotente:
+// se o item já criou um ecoponto (tentativa anterior interrompida), retorna o existente.
+func (r *Repository) CreateFromJobItem(ctx context.Context, tenantID, jobItemID string, req CreateEcoPontoRequest, lat, lon float64) (*EcoPonto, error) {
+	return r.insert(ctx, tenantID, &jobItemID, req, lat, lon)
+}
+
+// insert faz o INSERT; com job_item_id nulo o ON CONFLICT nunca dispara
+func (r *Repository) insert(ctx context.Context, tenantID string, jobItemID *string, req CreateEcoPontoRequest, lat, lon float64) (*EcoPonto, error) {
 	query := `
 		INSERT INTO ecopontos (
-			nome, tipo_residuo, logradouro, bairro, coordenadas, 
-			horario_funcionamento, foto_url
+			nome, tipo_residuo, logradouro, bairro, coordenadas,
+			horario_funcionamento, foto_url,
+			telefone, whatsapp, website, email,
+			acessivel_cadeirante, estacionamento, drive_thru,
+			organizacao_id, tenant_id, cidade, estado, job_item_id
 		)
-		VALUES ($1, $2, $3, $4, ST_SetSRID(ST_MakePoint($5, $6), 4326), $7, $8)
-		RETURNING id, nome, tipo_residuo, logradouro, bairro, created_at, 
-				  horario_funcionamento, foto_url
-	`
+		VALUES (
+			$1, $2, $3, $4, ST_SetSRID(ST_MakePoint($5, $6), 4326),
+			$7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+		)
+		ON CONFLICT (job_item_id) DO UPDATE SET job_item_id = EXCLUDED.job_item_id
+		RETURNING ` + ecopontoColumns
 
 	var novoPonto EcoPonto
 	err := r.db.QueryRowxContext(
@@ -41,37 +72,67 @@ func (r *Repository) Create(ctx context.Context, req CreateEcoPontoRequest, lat,
 		lon,
 		lat,
 		req.HorarioFuncionamento,
-		req.FotoURL,              
+		req.FotoURL,
+		req.Telefone,
+		req.WhatsApp,
+		req.Website,
+		req.Email,
+		req.AcessivelCadeirante,
+		req.Estacionamento,
+		req.DriveThru,
+		req.OrganizacaoID,
+		tenantID,
+		req.Cidade,
+		req.Estado,
+		jobItemID,
 	).StructScan(&novoPonto)
 
 	if err != nil {
 		return nil, err
 	}
-	novoPonto.Latitude = lat
-	novoPonto.Longitude = lon
 	return &novoPonto, nil
 }
 
-// Adiciona os novos campos ao SELECT
-func (r *Repository) ListByProximity(ctx context.Context, params ListByProximityParams) ([]EcoPonto, error) {
-	query := `
-		SELECT 
-			id, nome, tipo_residuo, logradouro, bairro, created_at,
-			horario_funcionamento, foto_url, -- <--- ADICIONADO
-			ST_X(coordenadas::geometry) AS longitude,
-			ST_Y(coordenadas::geometry) AS latitude
-		FROM 
-			ecopontos
-		WHERE 
-			ST_DWithin(
-				coordenadas,
-				ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography,
-				$3
-			)
-			AND ($4 = '' OR tipo_residuo = $4)
-	`
+// ListByProximity busca os ecopontos dentro do raio, do mais próximo ao mais distante
+func (r *Repository) ListByProximity(ctx context.Context, tenantID string, params ListByProximityParams) ([]EcoPonto, error) {
+	// 1. Monta a busca geográfica
+	qb := sq.Select(ecopontoColumns).
+		From("ecopontos").
+		PlaceholderFormat(sq.Dollar).
+		Where(sq.Eq{"tenant_id": tenantID}).
+		Where("ST_DWithin(coordenadas, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)",
+			params.Longitude, params.Latitude, params.Distancia).
+		OrderByClause("coordenadas::geography <-> ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography",
+			params.Longitude, params.Latitude)
+
+	// 2. Adiciona os filtros opcionais
+	if params.TipoResiduo != "" {
+		qb = qb.Where(sq.Eq{"tipo_residuo": params.TipoResiduo})
+	}
+	if params.OrganizacaoID != "" {
+		qb = qb.Where(sq.Eq{"organizacao_id": params.OrganizacaoID})
+	}
+	if params.AcessivelCadeirante != nil {
+		qb = qb.Where(sq.Eq{"acessivel_cadeirante": *params.AcessivelCadeirante})
+	}
+	if params.Estacionamento != nil {
+		qb = qb.Where(sq.Eq{"estacionamento": *params.Estacionamento})
+	}
+	if params.DriveThru != nil {
+		qb = qb.Where(sq.Eq{"drive_thru": *params.DriveThru})
+	}
+	if params.Limite > 0 {
+		qb = qb.Limit(uint64(params.Limite))
+	}
+
+	// 3. Constrói e executa a query
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
 	var pontos []EcoPonto
-	err := r.db.SelectContext(ctx, &pontos, query, params.Longitude, params.Latitude, params.Distancia, params.TipoResiduo)
+	err = r.db.SelectContext(ctx, &pontos, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -81,34 +142,31 @@ func (r *Repository) ListByProximity(ctx context.Context, params ListByProximity
 	return pontos, nil
 }
 
-// Adiciona os novos campos ao SELECT
-func (r *Repository) GetByID(ctx context.Context, id string) (*EcoPonto, error) {
+// GetByID busca um ecoponto pelo seu ID
+func (r *Repository) GetByID(ctx context.Context, tenantID, id string) (*EcoPonto, error) {
 	query := `
-		SELECT 
-			id, nome, tipo_residuo, logradouro, bairro, created_at,
-			horario_funcionamento, foto_url, -- <--- ADICIONADO
-			ST_X(coordenadas::geometry) AS longitude,
-			ST_Y(coordenadas::geometry) AS latitude
-		FROM 
+		SELECT ` + ecopontoColumns + `
+		FROM
 			ecopontos
-		WHERE 
-			id = $1
+		WHERE
+			id = $1 AND tenant_id = $2
 	`
 	var ponto EcoPonto
-	err := r.db.GetContext(ctx, &ponto, query, id)
+	err := r.db.GetContext(ctx, &ponto, query, id, tenantID)
 	if err != nil {
 		return nil, err
 	}
 	return &ponto, nil
 }
 
-// Adiciona os novos campos ao builder do squirrel 
-func (r *Repository) Update(ctx context.Context, id string, req UpdateEcoPontoRequest) (*EcoPonto, error) {
+// Update atualiza apenas os campos enviados (não nulos) no request;
+// os campos opcionais enviados como null ou "" (contato, organização) são apagados
+func (r *Repository) Update(ctx context.Context, tenantID, id string, req UpdateEcoPontoRequest) (*EcoPonto, error) {
 	// 1. Inicia o construtor de SQL
 	qb := sq.Update("ecopontos").
 		PlaceholderFormat(sq.Dollar).
-		Where(sq.Eq{"id": id}).
-		Suffix("RETURNING id, nome, tipo_residuo, logradouro, bairro, created_at, horario_funcionamento, foto_url, ST_X(coordenadas::geometry) AS longitude, ST_Y(coordenadas::geometry) AS latitude") 
+		Where(sq.Eq{"id": id, "tenant_id": tenantID}).
+		Suffix("RETURNING " + ecopontoColumns)
 
 	// 2. Adiciona campos de texto
 	if req.Nome != nil {
@@ -123,6 +181,12 @@ func (r *Repository) Update(ctx context.Context, id string, req UpdateEcoPontoRe
 	if req.Bairro != nil {
 		qb = qb.Set("bairro", *req.Bairro)
 	}
+	if req.Cidade != nil {
+		qb = qb.Set("cidade", *req.Cidade)
+	}
+	if req.Estado != nil {
+		qb = qb.Set("estado", *req.Estado)
+	}
 
 	if req.HorarioFuncionamento != nil {
 		qb = qb.Set("horario_funcionamento", *req.HorarioFuncionamento)
@@ -131,7 +195,36 @@ func (r *Repository) Update(ctx context.Context, id string, req UpdateEcoPontoRe
 		qb = qb.Set("foto_url", *req.FotoURL)
 	}
 
-	// 3. Lógica especial para coordenadas 
+	// 3. Adiciona campos de contato e acessibilidade
+	if req.Telefone != nil {
+		qb = qb.Set("telefone", *req.Telefone)
+	}
+	if req.WhatsApp != nil {
+		qb = qb.Set("whatsapp", *req.WhatsApp)
+	}
+	if req.Website != nil {
+		qb = qb.Set("website", *req.Website)
+	}
+	if req.Email != nil {
+		qb = qb.Set("email", *req.Email)
+	}
+	if req.AcessivelCadeirante != nil {
+		qb = qb.Set("acessivel_cadeirante", *req.AcessivelCadeirante)
+	}
+	if req.Estacionamento != nil {
+		qb = qb.Set("estacionamento", *req.Estacionamento)
+	}
+	if req.DriveThru != nil {
+		qb = qb.Set("drive_thru", *req.DriveThru)
+	}
+	if req.OrganizacaoID != nil {
+		qb = qb.Set("organizacao_id", *req.OrganizacaoID)
+	}
+	for _, campo := range req.limpar {
+		qb = qb.Set(campo, nil)
+	}
+
+	// 4. Lógica especial para coordenadas
 	if req.Latitude != nil && req.Longitude != nil {
 		qb = qb.Set("coordenadas", sq.Expr("ST_SetSRID(ST_MakePoint(?, ?), 4326)", *req.Longitude, *req.Latitude))
 	} else if req.Latitude != nil || req.Longitude != nil {
@@ -139,7 +232,7 @@ func (r *Repository) Update(ctx context.Context, id string, req UpdateEcoPontoRe
 		return nil, sql.ErrTxDone
 	}
 
-	// 4. Constrói e executa a query
+	// 5. Constrói e executa a query
 	query, args, err := qb.ToSql()
 	if err != nil {
 		return nil, err
@@ -155,11 +248,11 @@ func (r *Repository) Update(ctx context.Context, id string, req UpdateEcoPontoRe
 }
 
 // Delete remove um ecoponto do banco de dados pelo seu ID
-func (r *Repository) Delete(ctx context.Context, id string) error {
-	query := "DELETE FROM ecopontos WHERE id = $1"
+func (r *Repository) Delete(ctx context.Context, tenantID, id string) error {
+	query := "DELETE FROM ecopontos WHERE id = $1 AND tenant_id = $2"
 
 	// Executa a query
-	res, err := r.db.ExecContext(ctx, query, id)
+	res, err := r.db.ExecContext(ctx, query, id, tenantID)
 	if err != nil {
 		return err
 	}
@@ -178,20 +271,59 @@ func (r *Repository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *Repository) ListAll(ctx context.Context) ([]EcoPonto, error) {
+// ListAll lista todos os ecopontos, dos mais recentes aos mais antigos
+func (r *Repository) ListAll(ctx context.Context, tenantID string) ([]EcoPonto, error) {
 	query := `
-		SELECT 
-			id, nome, tipo_residuo, logradouro, bairro, created_at,
-			horario_funcionamento, foto_url, -- <--- ADICIONADO
-			ST_X(coordenadas::geometry) AS longitude,
-			ST_Y(coordenadas::geometry) AS latitude
-		FROM 
+		SELECT ` + ecopontoColumns + `
+		FROM
 			ecopontos
+		WHERE
+			tenant_id = $1
 		ORDER BY
 			created_at DESC
 	`
 	var pontos []EcoPonto
-	err := r.db.SelectContext(ctx, &pontos, query)
+	err := r.db.SelectContext(ctx, &pontos, query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if pontos == nil {
+		pontos = make([]EcoPonto, 0)
+	}
+	return pontos, nil
+}
+
+// CountByTipo conta os ecopontos de todos os municípios, por tipo de resíduo
+func (r *Repository) CountByTipo(ctx context.Context) ([]ContagemPorTipo, error) {
+	query := `
+		SELECT
+			t.slug AS municipio, e.tipo_residuo, COUNT(*) AS total
+		FROM
+			ecopontos e
+			JOIN tenants t ON t.id = e.tenant_id
+		GROUP BY
+			t.slug, e.tipo_residuo
+	`
+	var contagens []ContagemPorTipo
+	if err := r.db.SelectContext(ctx, &contagens, query); err != nil {
+		return nil, err
+	}
+	return contagens, nil
+}
+
+// ListByOrganizacao lista os ecopontos operados por uma organização
+func (r *Repository) ListByOrganizacao(ctx context.Context, tenantID, organizacaoID string) ([]EcoPonto, error) {
+	query := `
+		SELECT ` + ecopontoColumns + `
+		FROM
+			ecopontos
+		WHERE
+			organizacao_id = $1 AND tenant_id = $2
+		ORDER BY
+			nome
+	`
+	var pontos []EcoPonto
+	err := r.db.SelectContext(ctx, &pontos, query, organizacaoID, tenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,80 @@
+package geocoding
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Address é um endereço estruturado, no formato usado pelos ecopontos
+type Address struct {
+	Logradouro  string `json:"logradouro"`
+	Numero      string `json:"numero,omitempty"`
+	Bairro      string `json:"bairro"`
+	Cidade      string `json:"cidade"`
+	Estado      string `json:"estado"` // Sigla (UF), ex.: PE
+	CEP         string `json:"cep"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// Mismatches compara o logradouro e o bairro informados com este endereço
+// (obtido das coordenadas) e retorna um aviso para cada divergência.
+// Campos vazios de qualquer um dos lados não são comparados.
+func (a *Address) Mismatches(logradouro, bairro string) []string {
+	var avisos []string
+	if !sameName(logradouro, a.Logradouro) {
+		avisos = append(avisos, fmt.Sprintf(
+			"O logradouro informado (%q) difere do encontrado nas coordenadas (%q)", logradouro, a.Logradouro))
+	}
+	if !sameName(bairro, a.Bairro) {
+		avisos = append(avisos, fmt.Sprintf(
+			"O bairro informado (%q) difere do encontrado nas coordenadas (%q)", bairro, a.Bairro))
+	}
+	return avisos
+}
+
+// sameName compara dois nomes ignorando acentos, pontuação e maiúsculas.
+// Considera iguais se um contém o outro (ex.: "Aurora" e "Rua da Aurora").
+func sameName(informado, encontrado string) bool {
+	a, b := NormalizeAddress(informado), NormalizeAddress(encontrado)
+	if a == "" || b == "" {
+		return true
+	}
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
+
+// ufPorNome converte o nome do estado na sigla (UF)
+var ufPorNome = map[string]string{
+	"acre": "AC", "alagoas": "AL", "amapa": "AP", "amazonas": "AM", "bahia": "BA",
+	"ceara": "CE", "distrito federal": "DF", "espirito santo": "ES", "goias": "GO",
+	"maranhao": "MA", "mato grosso": "MT", "mato grosso do sul": "MS", "minas gerais": "MG",
+	"para": "PA", "paraiba": "PB", "parana": "PR", "pernambuco": "PE", "piaui": "PI",
+	"rio de janeiro": "RJ", "rio grande do norte": "RN", "rio grande do sul": "RS",
+	"rondonia": "RO", "roraima": "RR", "santa catarina": "SC", "sao paulo": "SP",
+	"sergipe": "SE", "tocantins": "TO",
+}
+
+// toUF converte o estado retornado pelo provedor na sigla (UF).
+// Aceita o código ISO 3166-2 (ex.: BR-PE), a própria sigla ou o nome do estado.
+func toUF(estado string) string {
+	estado = strings.TrimSpace(estado)
+	if uf, ok := strings.CutPrefix(strings.ToUpper(estado), "BR-"); ok {
+		return uf
+	}
+	if len(estado) == 2 {
+		return strings.ToUpper(estado)
+	}
+	if uf, ok := ufPorNome[NormalizeAddress(estado)]; ok {
+		return uf
+	}
+	return estado
+}
+
+// firstNonEmpty retorna o primeiro valor não vazio
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package geocoding
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Cached é um Geocoder que consulta o cache no banco antes de chamar o provedor.
+// Endereços não encontrados também são guardados (cache negativo), com um TTL menor.
+type Cached struct {
+	next        Geocoder
+	repo        *CacheRepository
+	provider    string // Nome do provedor, registrado nas entradas negativas
+	ttl         time.Duration
+	negativeTTL time.Duration
+}
+
+// NewCached envolve o Geocoder informado com o cache persistente
+func NewCached(next Geocoder, repo *CacheRepository, provider string, ttl, negativeTTL time.Duration) *Cached {
+	return &Cached{
+		next:        next,
+		repo:        repo,
+		provider:    provider,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// Geocode retorna as coordenadas do cache ou, se não houver, do provedor
+func (c *Cached) Geocode(ctx context.Context, address string) (*Result, error) {
+	key := NormalizeAddress(address)
+
+	// 1. Consulta o cache. Falhas no cache não impedem o geocoding.
+	entry, err := c.repo.Get(ctx, key)
+	if err == nil {
+		if !entry.Encontrado {
+			return nil, ErrNotFound
+		}
+		return entry.toResult(), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(ctx, "Erro ao consultar o cache de geocoding", "erro", err)
+	}
+
+	// 2. Chama o provedor
+	res, err := c.next.Geocode(ctx, address)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		// Erros do provedor (fora do ar, timeout...) não são cacheados
+		return nil, err
+	}
+
+	// 3. Guarda o resultado (positivo ou negativo) no cache
+	entry = &CacheEntry{
+		EnderecoNormalizado: key,
+		Endereco:            address,
+		Encontrado:          res != nil,
+	}
+	if res != nil {
+		entry.Provider = res.Provider
+		entry.Latitude = &res.Latitude
+		entry.Longitude = &res.Longitude
+		entry.Confianca = &res.Confianca
+		entry.ExpiresAt = time.Now().Add(c.ttl)
+	} else {
+		entry.Provider = c.provider
+		entry.ExpiresAt = time.Now().Add(c.negativeTTL)
+	}
+	if saveErr := c.repo.Save(ctx, *entry); saveErr != nil {
+		slog.ErrorContext(ctx, "Erro ao salvar no cache de geocoding", "erro", saveErr)
+	}
+
+	return res, err
+}
+
+// Reverse não usa o cache: as coordenadas de pins arrastados raramente se repetem
+func (c *Cached) Reverse(ctx context.Context, lat, lon float64) (*Address, error) {
+	return c.next.Reverse(ctx, lat, lon)
+}
+
+// Search não usa o cache: o texto muda a cada tecla digitada no autocomplete
+func (c *Cached) Search(ctx context.Context, query string, opts SearchOptions) ([]Candidate, error) {
+	return c.next.Search(ctx, query, opts)
+}
+
+// Ping verifica o provedor por trás do cache
+func (c *Cached) Ping(ctx context.Context) error {
+	return Ping(ctx, c.next)
+}
+
+// toResult converte uma entrada positiva do cache em Result
+func (e *CacheEntry) toResult() *Result {
+	res := &Result{Provider: e.Provider}
+	if e.Latitude != nil && e.Longitude != nil {
+		res.Latitude = *e.Latitude
+		res.Longitude = *e.Longitude
+	}
+	if e.Confianca != nil {
+		res.Confianca = *e.Confianca
+	}
+	return res
+}
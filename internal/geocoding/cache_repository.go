@@ -0,0 +1,127 @@
+package geocoding
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CacheEntry é uma entrada do cache de geocoding
+type CacheEntry struct {
+	EnderecoNormalizado string    `db:"endereco_normalizado" json:"endereco_normalizado"`
+	Endereco            string    `db:"endereco" json:"endereco"`
+	Provider            string    `db:"provider" json:"provider"`
+	Encontrado          bool      `db:"encontrado" json:"encontrado"`
+	Latitude            *float64  `db:"latitude" json:"latitude,omitempty"`
+	Longitude           *float64  `db:"longitude" json:"longitude,omitempty"`
+	Confianca           *float64  `db:"confianca" json:"confianca,omitempty"`
+	ExpiresAt           time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt           time.Time `db:"created_at" json:"created_at"`
+}
+
+// CacheRepository gerencia a persistência do cache de geocoding
+type CacheRepository struct {
+	db *sqlx.DB
+}
+
+// NewCacheRepository cria uma nova instância do repositório
+func NewCacheRepository(db *sqlx.DB) *CacheRepository {
+	return &CacheRepository{db: db}
+}
+
+// Get busca uma entrada ainda válida (não expirada) pelo endereço normalizado
+func (r *CacheRepository) Get(ctx context.Context, enderecoNormalizado string) (*CacheEntry, error) {
+	query := `
+		SELECT * FROM geocoding_cache
+		WHERE endereco_normalizado = $1 AND expires_at > NOW()
+	`
+	var e CacheEntry
+	err := r.db.GetContext(ctx, &e, query, enderecoNormalizado)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Save grava (ou substitui) uma entrada do cache
+func (r *CacheRepository) Save(ctx context.Context, e CacheEntry) error {
+	query := `
+		INSERT INTO geocoding_cache (
+			endereco_normalizado, endereco, provider, encontrado,
+			latitude, longitude, confianca, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (endereco_normalizado) DO UPDATE SET
+			endereco = EXCLUDED.endereco,
+			provider = EXCLUDED.provider,
+			encontrado = EXCLUDED.encontrado,
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			confianca = EXCLUDED.confianca,
+			expires_at = EXCLUDED.expires_at,
+			created_at = NOW()
+	`
+	_, err := r.db.ExecContext(ctx, query,
+		e.EnderecoNormalizado, e.Endereco, e.Provider, e.Encontrado,
+		e.Latitude, e.Longitude, e.Confianca, e.ExpiresAt,
+	)
+	return err
+}
+
+// List lista as entradas do cache (inclusive expiradas), das mais recentes às mais antigas.
+// O filtro, se informado, é aplicado sobre o endereço normalizado.
+func (r *CacheRepository) List(ctx context.Context, filtro string, limite, offset int) ([]CacheEntry, error) {
+	query := `
+		SELECT * FROM geocoding_cache
+		WHERE ($1 = '' OR endereco_normalizado LIKE '%' || $1 || '%')
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	var entries []CacheEntry
+	err := r.db.SelectContext(ctx, &entries, query, NormalizeAddress(filtro), limite, offset)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = make([]CacheEntry, 0)
+	}
+	return entries, nil
+}
+
+// Delete remove a entrada de um endereço e retorna quantas linhas foram removidas
+func (r *CacheRepository) Delete(ctx context.Context, endereco string) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM geocoding_cache WHERE endereco_normalizado = $1", NormalizeAddress(endereco))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeletePrefix remove as entradas cujo endereço normalizado começa com o prefixo
+// e retorna quantas linhas foram removidas. A normalização deixa só letras,
+// números e espaços, então o prefixo não tem curingas do LIKE.
+func (r *CacheRepository) DeletePrefix(ctx context.Context, prefixo string) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		"DELETE FROM geocoding_cache WHERE endereco_normalizado LIKE $1 || '%'", NormalizeAddress(prefixo))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Purge remove as entradas do cache (todas ou só as expiradas)
+// e retorna quantas linhas foram removidas
+func (r *CacheRepository) Purge(ctx context.Context, apenasExpiradas bool) (int64, error) {
+	query := "DELETE FROM geocoding_cache"
+	if apenasExpiradas {
+		query += " WHERE expires_at <= NOW()"
+	}
+
+	res, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
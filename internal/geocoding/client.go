@@ -0,0 +1,323 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/time/rate"
+)
+
+// ErrCircuitOpen é retornado, sem chamar o provedor, enquanto ele é considerado fora do ar
+var ErrCircuitOpen = errors.New("serviço de geocoding indisponível: muitas falhas seguidas, novas chamadas suspensas temporariamente")
+
+// StatusError é retornado quando o provedor responde com um status HTTP de erro
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API %s retornou status: %s", e.Provider, e.Status)
+}
+
+// retryable indica se vale a pena repetir a chamada (limite de taxa ou erro do servidor)
+func (e *StatusError) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// ClientOptions configura o cliente HTTP compartilhado pelos provedores
+type ClientOptions struct {
+	Timeout          time.Duration // Tempo máximo de cada tentativa
+	RateLimit        float64       // Requisições por segundo (o Nominatim público permite 1)
+	MaxRetries       int           // Tentativas extras em 429/5xx/erro de rede
+	BackoffBase      time.Duration // Espera antes da 1ª repetição (dobra a cada tentativa)
+	BreakerThreshold int           // Falhas seguidas que abrem o circuit breaker
+	BreakerCooldown  time.Duration // Tempo com o circuito aberto antes de testar de novo
+}
+
+// DefaultClientOptions são os valores usados quando a configuração não informa outros
+var DefaultClientOptions = ClientOptions{
+	Timeout:          10 * time.Second,
+	RateLimit:        1,
+	MaxRetries:       3,
+	BackoffBase:      500 * time.Millisecond,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// Client é o cliente HTTP compartilhado por todos os provedores do processo.
+// Respeita o context.Context da requisição, limita a taxa de chamadas (token bucket),
+// repete com backoff exponencial em 429/5xx e para de chamar o provedor
+// (circuit breaker) quando ele parece estar fora do ar.
+type Client struct {
+	http        *http.Client
+	limiter     *rate.Limiter
+	maxRetries  int
+	backoffBase time.Duration
+	breaker     *circuitBreaker
+}
+
+// NewClient cria o cliente compartilhado. Campos zerados usam DefaultClientOptions.
+func NewClient(opts ClientOptions) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultClientOptions.Timeout
+	}
+	if opts.RateLimit <= 0 {
+		opts.RateLimit = DefaultClientOptions.RateLimit
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = DefaultClientOptions.BackoffBase
+	}
+	if opts.BreakerThreshold <= 0 {
+		opts.BreakerThreshold = DefaultClientOptions.BreakerThreshold
+	}
+	if opts.BreakerCooldown <= 0 {
+		opts.BreakerCooldown = DefaultClientOptions.BreakerCooldown
+	}
+
+	// Um span por chamada ao provedor (cada repetição é um span), com o traceparent propagado
+	transport := otelhttp.NewTransport(http.DefaultTransport,
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return "geocoder " + r.Method + " " + r.URL.Path
+		}),
+	)
+
+	return &Client{
+		http:        &http.Client{Timeout: opts.Timeout, Transport: transport},
+		limiter:     rate.NewLimiter(rate.Limit(opts.RateLimit), 1),
+		maxRetries:  opts.MaxRetries,
+		backoffBase: opts.BackoffBase,
+		breaker: &circuitBreaker{
+			threshold: opts.BreakerThreshold,
+			cooldown:  opts.BreakerCooldown,
+		},
+	}
+}
+
+// getJSON faz um GET na URL e decodifica o JSON da resposta em out
+func (c *Client) getJSON(ctx context.Context, provider, url, userAgent string, out any) error {
+	// 1. Falha rápido se o provedor está fora do ar
+	probe, ok := c.breaker.allow()
+	if !ok {
+		return ErrCircuitOpen
+	}
+	// Se sair sem veredito (ex.: context cancelado), libera a chamada de teste
+	defer c.breaker.release(probe)
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		// 2. Espera o intervalo entre as tentativas (backoff exponencial com jitter)
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt, lastErr)); err != nil {
+				return err
+			}
+		}
+
+		// 3. Espera a vez no limitador de taxa (respeitando o prazo do context)
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		// 4. Faz a chamada
+		retryAfter, err := c.do(ctx, provider, url, userAgent, out)
+		if err == nil {
+			c.breaker.success(probe)
+			return nil
+		}
+		lastErr = &attemptError{err: err, retryAfter: retryAfter}
+
+		// 5. Só repete erros transitórios; o context cancelado encerra na hora
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !isTransient(err) {
+			// O provedor respondeu (ex.: 400), então está no ar
+			c.breaker.success(probe)
+			return err
+		}
+	}
+
+	c.breaker.failure(probe)
+	return errors.Unwrap(lastErr)
+}
+
+// ping faz uma única chamada ao provedor, sem repetições, para o health check.
+// Respeita o limite de taxa, mas não conta para o circuit breaker.
+func (c *Client) ping(ctx context.Context, provider, url, userAgent string) error {
+	if c.breaker.open() {
+		return ErrCircuitOpen
+	}
+	if err := c.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	var out any
+	_, err := c.do(ctx, provider, url, userAgent, &out)
+	return err
+}
+
+// do executa uma única tentativa. Em 429 retorna o Retry-After informado pelo provedor.
+func (c *Client) do(ctx context.Context, provider, url, userAgent string, out any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var retryAfter time.Duration
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(secs) * time.Second
+		}
+		return retryAfter, &StatusError{Provider: provider, StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return 0, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// backoff calcula a espera antes da tentativa informada
+func (c *Client) backoff(attempt int, lastErr error) time.Duration {
+	d := c.backoffBase << (attempt - 1)
+	d += time.Duration(rand.Int64N(int64(d)/2 + 1)) // jitter de até 50%
+
+	var ae *attemptError
+	if errors.As(lastErr, &ae) && ae.retryAfter > d {
+		d = ae.retryAfter
+	}
+	return d
+}
+
+// attemptError guarda o erro de uma tentativa junto com o Retry-After recebido
+type attemptError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *attemptError) Error() string { return e.err.Error() }
+func (e *attemptError) Unwrap() error { return e.err }
+
+// isTransient indica se o erro pode sumir numa nova tentativa
+func isTransient(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.retryable()
+	}
+	// JSON inválido não melhora ao repetir; erros de rede/timeout sim
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return !errors.As(err, &syntaxErr) && !errors.As(err, &typeErr)
+}
+
+// sleep espera a duração informada ou até o context ser cancelado
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// circuitBreaker abre após N falhas seguidas e, passado o cooldown,
+// deixa uma única chamada de teste passar (meio-aberto)
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool // Há uma chamada de teste em andamento
+}
+
+// allow indica se a chamada pode ser feita e se ela é a chamada de teste.
+// Só a chamada de teste libera a vaga de teste (em success, failure ou release):
+// uma chamada liberada antes de o circuito abrir não a libera ao terminar.
+func (b *circuitBreaker) allow() (probe, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return false, true // Fechado
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false // Aberto, ou já testando
+	}
+	b.probing = true // Meio-aberto: libera uma chamada de teste
+	return true, true
+}
+
+// open indica se o circuito está aberto, sem liberar a chamada de teste
+func (b *circuitBreaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold && time.Now().Before(b.openUntil)
+}
+
+func (b *circuitBreaker) success(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	if probe {
+		b.probing = false
+	}
+}
+
+func (b *circuitBreaker) release(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+}
+
+func (b *circuitBreaker) failure(probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if probe {
+		b.probing = false
+	}
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// isTimeout indica se o erro é um timeout de rede (ex.: Client.Timeout excedido)
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// ErrorResponse traduz um erro do provedor (que não seja ErrNotFound)
+// no status HTTP e na mensagem a devolver ao cliente da API
+func ErrorResponse(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return http.StatusServiceUnavailable, "Serviço de geocoding temporariamente indisponível. Tente novamente em instantes"
+	case errors.Is(err, context.DeadlineExceeded) || isTimeout(err):
+		return http.StatusGatewayTimeout, "Serviço de geocoding não respondeu a tempo"
+	default:
+		return http.StatusBadGateway, "Serviço de geocoding indisponível"
+	}
+}
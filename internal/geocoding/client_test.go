@@ -0,0 +1,50 @@
+package geocoding
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOnlyProbeReleasesSlot(t *testing.T) {
+	b := &circuitBreaker{threshold: 2, cooldown: time.Millisecond}
+
+	// 1. Duas chamadas liberadas com o circuito fechado; a primeira falha...
+	if probe, ok := b.allow(); !ok || probe {
+		t.Fatalf("circuito fechado: allow = (%v, %v)", probe, ok)
+	}
+	b.failure(false)
+
+	// ...e a segunda, que abre o circuito
+	b.failure(false)
+	if _, ok := b.allow(); ok {
+		t.Fatal("circuito deveria estar aberto")
+	}
+
+	// 2. Passado o cooldown, só uma chamada de teste passa
+	time.Sleep(2 * time.Millisecond)
+	probe, ok := b.allow()
+	if !ok || !probe {
+		t.Fatalf("meio-aberto: allow = (%v, %v), esperado a chamada de teste", probe, ok)
+	}
+	if _, ok := b.allow(); ok {
+		t.Fatal("segunda chamada liberada durante o teste")
+	}
+
+	// 3. Uma chamada antiga (não é a de teste) terminando não libera a vaga
+	b.release(false)
+	if _, ok := b.allow(); ok {
+		t.Fatal("release de outra chamada liberou a vaga de teste")
+	}
+
+	// 4. A chamada de teste saindo sem veredito libera a vaga para a próxima
+	b.release(true)
+	if probe, ok := b.allow(); !ok || !probe {
+		t.Fatalf("após o release do teste: allow = (%v, %v)", probe, ok)
+	}
+
+	// 5. O sucesso do teste fecha o circuito
+	b.success(true)
+	if probe, ok := b.allow(); !ok || probe {
+		t.Fatalf("circuito deveria estar fechado: allow = (%v, %v)", probe, ok)
+	}
+}
@@ -1,67 +1,85 @@
 package geocoding
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
-	"strconv"
 )
 
-// NominatimResult define a estrutura da resposta da API Nominatim
-// A API retorna uma lista, mesmo que seja só um resultado
-type NominatimResult struct {
-	Lat string `json:"lat"`
-	Lon string `json:"lon"`
-}
+// Provedores de geocoding disponíveis (config GEOCODER_PROVIDER)
+const (
+	ProviderNominatim = "nominatim"
+	ProviderPhoton    = "photon"
+	ProviderStatic    = "static"
+)
 
-// GetCoordsFromAddress converte um endereço em coordenadas
-func GetCoordsFromAddress(address string) (float64, float64, error) {
-	// A API Nominatim requer um User-Agent.
-	// Substitua "EcopontoApp" pelo nome do seu projeto.
-	const userAgent = "EcopontoApp/1.0 (oiericoi@hotmail.com)"
+// ErrNotFound é retornado quando o provedor não encontra o endereço
+var ErrNotFound = errors.New("endereço não encontrado")
 
-	// Monta a URL
-	baseURL := "https://nominatim.openstreetmap.org/search"
-	query := fmt.Sprintf("?q=%s&format=json&limit=1", url.QueryEscape(address))
+// Geocoder converte endereços em coordenadas (e coordenadas em endereços).
+// Os handlers dependem desta interface, e não de um provedor específico,
+// para que possam ser testados offline e o provedor possa ser trocado.
+type Geocoder interface {
+	// Geocode converte um endereço em coordenadas.
+	// Retorna ErrNotFound se o endereço não for encontrado.
+	Geocode(ctx context.Context, address string) (*Result, error)
 
-	// Cria a requisição
-	req, err := http.NewRequest("GET", baseURL+query, nil)
-	if err != nil {
-		return 0, 0, err
-	}
-	req.Header.Set("User-Agent", userAgent)
+	// Reverse converte coordenadas no endereço mais próximo.
+	// Retorna ErrNotFound se não houver endereço no local.
+	Reverse(ctx context.Context, lat, lon float64) (*Address, error)
 
-	// Faz a chamada HTTP
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, 0, err
-	}
-	defer resp.Body.Close()
+	// Search retorna vários candidatos para o texto, restritos ao Brasil,
+	// para o admin escolher o endereço certo antes de salvar
+	Search(ctx context.Context, query string, opts SearchOptions) ([]Candidate, error)
+}
 
-	if resp.StatusCode != 200 {
-		return 0, 0, fmt.Errorf("API Nominatim retornou status: %s", resp.Status)
-	}
+// Pinger é implementado pelos provedores que dependem de um serviço externo
+type Pinger interface {
+	// Ping verifica se o serviço do provedor está no ar
+	Ping(ctx context.Context) error
+}
 
-	// Lê o JSON da resposta
-	var results []NominatimResult
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return 0, 0, err
+// Ping verifica se o provedor do geocoder está acessível.
+// Provedores locais (static) não implementam Pinger e estão sempre disponíveis.
+func Ping(ctx context.Context, g Geocoder) error {
+	if p, ok := g.(Pinger); ok {
+		return p.Ping(ctx)
 	}
+	return nil
+}
 
-	// Verifica se encontrou algum resultado
-	if len(results) == 0 {
-		return 0, 0, errors.New("endereço não encontrado")
-	}
+// Result é o resultado de uma geocodificação
+type Result struct {
+	Latitude  float64
+	Longitude float64
+	Confianca float64 // Entre 0 e 1, quando o provedor informa
+	Provider  string
+}
 
-	// Converte as strings Lat/Lon para float64
-	lat, errLat := strconv.ParseFloat(results[0].Lat, 64)
-	lon, errLon := strconv.ParseFloat(results[0].Lon, 64)
-	if errLat != nil || errLon != nil {
-		return 0, 0, errors.New("erro ao converter coordenadas")
-	}
+// Options configura a criação do Geocoder
+type Options struct {
+	Provider   string // nominatim, photon ou static
+	BaseURL    string // URL base da API (nominatim e photon)
+	UserAgent  string // User-Agent enviado ao provedor
+	StaticFile string // Arquivo JSON com os endereços conhecidos (static)
 
-	return lat, lon, nil
+	// Client configura o cliente HTTP (timeout, limite de taxa, retries, circuit breaker)
+	Client ClientOptions
+}
+
+// New cria o Geocoder do provedor configurado
+func New(opts Options) (Geocoder, error) {
+	switch opts.Provider {
+	case ProviderNominatim, "":
+		return NewNominatim(opts.BaseURL, opts.UserAgent, NewClient(opts.Client)), nil
+	case ProviderPhoton:
+		if opts.BaseURL == "" {
+			return nil, errors.New("GEOCODER_URL é obrigatória para o provedor photon")
+		}
+		return NewPhoton(opts.BaseURL, opts.UserAgent, NewClient(opts.Client)), nil
+	case ProviderStatic:
+		return NewStatic(opts.StaticFile)
+	default:
+		return nil, fmt.Errorf("provedor de geocoding desconhecido: %q", opts.Provider)
+	}
 }
@@ -0,0 +1,176 @@
+package geocoding
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/gin-gonic/gin"
+)
+
+// minPrefixoCache é o tamanho mínimo (normalizado) do prefixo aceito na limpeza do cache
+const minPrefixoCache = 3
+
+// Handler gerencia as requisições HTTP de geocoding usadas pelo painel admin
+type Handler struct {
+	geocoder  Geocoder
+	cacheRepo *CacheRepository
+
+	// isOperator indica se quem fez a requisição é operador da plataforma. O cache
+	// é compartilhado por todos os municípios: listar, apagar por prefixo ou
+	// apagar tudo fica restrito a eles
+	isOperator func(c *gin.Context) bool
+}
+
+// NewHandler cria uma nova instância do handler
+func NewHandler(geocoder Geocoder, cacheRepo *CacheRepository, isOperator func(c *gin.Context) bool) *Handler {
+	return &Handler{geocoder: geocoder, cacheRepo: cacheRepo, isOperator: isOperator}
+}
+
+// requireOperator responde 403 se quem fez a requisição não é operador da plataforma
+func (h *Handler) requireOperator(c *gin.Context, msg string) bool {
+	if h.isOperator == nil || !h.isOperator(c) {
+		apierror.Respond(c, http.StatusForbidden, msg)
+		return false
+	}
+	return true
+}
+
+// Reverse é o método para o endpoint GET /api/geocode/reverse?lat=&lon=
+// Retorna o endereço das coordenadas para o painel pré-preencher o formulário
+func (h *Handler) Reverse(c *gin.Context) {
+	// 1. Ler e validar as coordenadas
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, errLon := strconv.ParseFloat(c.Query("lon"), 64)
+	if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetros 'lat' e 'lon' são obrigatórios e devem ser coordenadas válidas")
+		return
+	}
+
+	// 2. Chamar o geocoder
+	addr, err := h.geocoder.Reverse(c.Request.Context(), lat, lon)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			apierror.Respond(c, http.StatusNotFound, "Nenhum endereço encontrado nestas coordenadas")
+			return
+		}
+		slog.WarnContext(c.Request.Context(), "Erro no geocoding reverso", "erro", err)
+		status, msg := ErrorResponse(err)
+		apierror.Respond(c, status, msg)
+		return
+	}
+
+	c.JSON(http.StatusOK, addr)
+}
+
+// Search é o método para o endpoint GET /api/geocode/search?q=
+// Query params: q (mín. 3 caracteres), limite (padrão 5, máx. 20) e
+// viewbox (opcional, "minLon,minLat,maxLon,maxLat") para restringir à cidade
+func (h *Handler) Search(c *gin.Context) {
+	// 1. Ler e validar os parâmetros
+	q := strings.TrimSpace(c.Query("q"))
+	if len([]rune(q)) < 3 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'q' deve ter pelo menos 3 caracteres")
+		return
+	}
+
+	limite, err := strconv.Atoi(c.DefaultQuery("limite", "5"))
+	if err != nil || limite <= 0 || limite > 20 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'limite' inválido (1 a 20)")
+		return
+	}
+
+	opts := SearchOptions{Limite: limite}
+	if vb := c.Query("viewbox"); vb != "" {
+		if opts.Viewbox, err = ParseViewbox(vb); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'viewbox' inválido: "+err.Error())
+			return
+		}
+	}
+
+	// 2. Chamar o geocoder
+	candidatos, err := h.geocoder.Search(c.Request.Context(), q, opts)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Erro na busca de endereços", "erro", err)
+		status, msg := ErrorResponse(err)
+		apierror.Respond(c, status, msg)
+		return
+	}
+
+	// 3. Retornar os candidatos (mesmo que a lista esteja vazia)
+	c.JSON(http.StatusOK, candidatos)
+}
+
+// ListCache é o método para o endpoint GET /api/geocode/cache
+// Query params: q (filtro pelo endereço), limite (padrão 50, máx. 500), offset
+// Restrito aos operadores da plataforma: o cache tem os endereços de todos os municípios
+func (h *Handler) ListCache(c *gin.Context) {
+	if !h.requireOperator(c, "Listar o cache de geocoding é restrito aos operadores da plataforma") {
+		return
+	}
+
+	// 1. Ler a paginação
+	limite, err := strconv.Atoi(c.DefaultQuery("limite", "50"))
+	if err != nil || limite <= 0 || limite > 500 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'limite' inválido (1 a 500)")
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'offset' inválido")
+		return
+	}
+
+	// 2. Chamar o repositório
+	entries, err := h.cacheRepo.List(c.Request.Context(), c.Query("q"), limite, offset)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// PurgeCache é o método para o endpoint DELETE /api/geocode/cache
+// ?endereco= apaga um endereço; ?expirados=true apaga só as entradas expiradas.
+// ?prefixo= apaga os endereços que começam com ele e ?todos=true apaga o cache
+// inteiro; os dois são restritos aos operadores da plataforma, já que o cache é
+// compartilhado por todos os municípios.
+func (h *Handler) PurgeCache(c *gin.Context) {
+	var removidos int64
+	var err error
+
+	switch {
+	case c.Query("endereco") != "":
+		removidos, err = h.cacheRepo.Delete(c.Request.Context(), c.Query("endereco"))
+	case c.Query("prefixo") != "":
+		if !h.requireOperator(c, "Apagar o cache por prefixo é restrito aos operadores da plataforma") {
+			return
+		}
+		if len([]rune(NormalizeAddress(c.Query("prefixo")))) < minPrefixoCache {
+			apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'prefixo' deve ter ao menos 3 letras ou números")
+			return
+		}
+		removidos, err = h.cacheRepo.DeletePrefix(c.Request.Context(), c.Query("prefixo"))
+	case c.Query("expirados") == "true":
+		removidos, err = h.cacheRepo.Purge(c.Request.Context(), true)
+	case c.Query("todos") == "true":
+		if !h.requireOperator(c, "Apagar o cache inteiro é restrito aos operadores da plataforma") {
+			return
+		}
+		removidos, err = h.cacheRepo.Purge(c.Request.Context(), false)
+	default:
+		apierror.Respond(c, http.StatusBadRequest, "Informe 'endereco', 'prefixo', 'expirados=true' ou 'todos=true'")
+		return
+	}
+
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"removidos": removidos})
+}
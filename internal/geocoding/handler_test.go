@@ -0,0 +1,164 @@
+package geocoding
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// testEntries são os endereços conhecidos pelo geocoder estático dos testes
+var testEntries = []StaticEntry{
+	{
+		Endereco: "Rua da Aurora, Boa Vista, Recife, PE", Latitude: -8.0631, Longitude: -34.8811,
+		Logradouro: "Rua da Aurora", Bairro: "Boa Vista", Cidade: "Recife", Estado: "Pernambuco",
+	},
+	{
+		Endereco: "Rua da Aurora, Centro, Olinda, PE", Latitude: -8.0089, Longitude: -34.8553,
+		Logradouro: "Rua da Aurora", Bairro: "Centro", Cidade: "Olinda", Estado: "Pernambuco",
+	},
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter monta as rotas de geocoding sobre o geocoder estático e o banco falso.
+// operador indica se quem chama é operador da plataforma.
+func newTestRouter(t *testing.T, operador bool) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("consultas esperadas não executadas: %v", err)
+		}
+		db.Close()
+	})
+
+	h := NewHandler(NewStaticFromEntries(testEntries), NewCacheRepository(sqlx.NewDb(db, "postgres")),
+		func(*gin.Context) bool { return operador })
+	r := gin.New()
+	r.GET("/api/geocode/search", h.Search)
+	r.GET("/api/geocode/reverse", h.Reverse)
+	r.GET("/api/geocode/cache", h.ListCache)
+	r.DELETE("/api/geocode/cache", h.PurgeCache)
+	return r, mock
+}
+
+func get(r http.Handler, method, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestSearch(t *testing.T) {
+	r, _ := newTestRouter(t, false)
+
+	tests := []struct {
+		name    string
+		target  string
+		status  int
+		cidades []string
+	}{
+		{"todos os candidatos", "/api/geocode/search?q=rua+da+aurora", http.StatusOK, []string{"Recife", "Olinda"}},
+		{"limite", "/api/geocode/search?q=rua+da+aurora&limite=1", http.StatusOK, []string{"Recife"}},
+		{"viewbox de Olinda", "/api/geocode/search?q=rua+da+aurora&viewbox=-34.87,-8.02,-34.84,-8.00", http.StatusOK, []string{"Olinda"}},
+		{"sem resultados", "/api/geocode/search?q=avenida+inexistente", http.StatusOK, []string{}},
+		{"busca curta", "/api/geocode/search?q=ru", http.StatusBadRequest, nil},
+		{"limite inválido", "/api/geocode/search?q=rua&limite=21", http.StatusBadRequest, nil},
+		{"viewbox inválido", "/api/geocode/search?q=rua&viewbox=1,2,3", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(r, http.MethodGet, tt.target)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, esperado %d (corpo %s)", w.Code, tt.status, w.Body)
+			}
+			if tt.cidades == nil {
+				return
+			}
+
+			var candidatos []Candidate
+			if err := json.Unmarshal(w.Body.Bytes(), &candidatos); err != nil {
+				t.Fatalf("resposta inválida: %v", err)
+			}
+			if len(candidatos) != len(tt.cidades) {
+				t.Fatalf("%d candidatos, esperados %d: %s", len(candidatos), len(tt.cidades), w.Body)
+			}
+			for i, c := range candidatos {
+				if c.Endereco.Cidade != tt.cidades[i] {
+					t.Errorf("candidato %d em %q, esperado %q", i, c.Endereco.Cidade, tt.cidades[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReverse(t *testing.T) {
+	r, _ := newTestRouter(t, false)
+
+	// Perto da entrada de Recife: devolve o endereço dela, com o estado em UF
+	w := get(r, http.MethodGet, "/api/geocode/reverse?lat=-8.0632&lon=-34.8812")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+	var addr Address
+	if err := json.Unmarshal(w.Body.Bytes(), &addr); err != nil {
+		t.Fatalf("resposta inválida: %v", err)
+	}
+	if addr.Bairro != "Boa Vista" || addr.Estado != "PE" {
+		t.Errorf("endereço inesperado: %+v", addr)
+	}
+
+	// Longe de todas as entradas
+	if w := get(r, http.MethodGet, "/api/geocode/reverse?lat=-23.55&lon=-46.63"); w.Code != http.StatusNotFound {
+		t.Errorf("status %d, esperado 404 (corpo %s)", w.Code, w.Body)
+	}
+
+	// Coordenadas fora do intervalo
+	if w := get(r, http.MethodGet, "/api/geocode/reverse?lat=91&lon=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, esperado 400 (corpo %s)", w.Code, w.Body)
+	}
+}
+
+func TestPurgeCacheAllRequiresPlatformOperator(t *testing.T) {
+	// Admin de um município: o cache compartilhado nem é tocado
+	r, _ := newTestRouter(t, false)
+	for _, target := range []string{"/api/geocode/cache?todos=true", "/api/geocode/cache?prefixo=rua+da"} {
+		if w := get(r, http.MethodDelete, target); w.Code != http.StatusForbidden {
+			t.Fatalf("%s: status %d, esperado 403 (corpo %s)", target, w.Code, w.Body)
+		}
+	}
+	if w := get(r, http.MethodGet, "/api/geocode/cache"); w.Code != http.StatusForbidden {
+		t.Fatalf("listagem: status %d, esperado 403 (corpo %s)", w.Code, w.Body)
+	}
+
+	// Operador da plataforma
+	r, mock := newTestRouter(t, true)
+	mock.ExpectExec(`^DELETE FROM geocoding_cache$`).WillReturnResult(sqlmock.NewResult(0, 7))
+	w := get(r, http.MethodDelete, "/api/geocode/cache?todos=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+	if w.Body.String() != `{"removidos":7}` {
+		t.Errorf("corpo %s", w.Body)
+	}
+}
+
+func TestPurgeCacheRequiresFilter(t *testing.T) {
+	r, _ := newTestRouter(t, true)
+
+	// Sem filtro, ou com um prefixo curto demais, nada é apagado
+	for _, target := range []string{"/api/geocode/cache", "/api/geocode/cache?prefixo=r%25", "/api/geocode/cache?todos=1"} {
+		if w := get(r, http.MethodDelete, target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, esperado 400 (corpo %s)", target, w.Code, w.Body)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package geocoding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultNominatimURL é a instância pública do OpenStreetMap
+	DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+	// DefaultUserAgent identifica a aplicação, como exige a política de uso do Nominatim
+	DefaultUserAgent = "EcopontoApp/1.0 (+https://github.com/EricOliveiras/ecoponto-api-)"
+)
+
+// NominatimResult define a estrutura da resposta da API Nominatim
+// A API retorna uma lista, mesmo que seja só um resultado
+type NominatimResult struct {
+	Lat        string  `json:"lat"`
+	Lon        string  `json:"lon"`
+	Importance float64 `json:"importance"`
+}
+
+// nominatimSearchResult define a estrutura de cada resultado do /search (format=jsonv2)
+type nominatimSearchResult struct {
+	Lat         string           `json:"lat"`
+	Lon         string           `json:"lon"`
+	DisplayName string           `json:"display_name"`
+	Type        string           `json:"type"`
+	Importance  float64          `json:"importance"`
+	Address     nominatimAddress `json:"address"`
+}
+
+// nominatimReverseResult define a estrutura da resposta do /reverse do Nominatim
+type nominatimReverseResult struct {
+	DisplayName string           `json:"display_name"`
+	Address     nominatimAddress `json:"address"`
+	Error       string           `json:"error"` // Preenchido quando não há endereço no local
+}
+
+// nominatimAddress são os campos de endereço (addressdetails=1) do Nominatim
+type nominatimAddress struct {
+	Road          string `json:"road"`
+	Pedestrian    string `json:"pedestrian"`
+	HouseNumber   string `json:"house_number"`
+	Suburb        string `json:"suburb"`
+	Neighbourhood string `json:"neighbourhood"`
+	CityDistrict  string `json:"city_district"`
+	City          string `json:"city"`
+	Town          string `json:"town"`
+	Village       string `json:"village"`
+	Municipality  string `json:"municipality"`
+	State         string `json:"state"`
+	StateCode     string `json:"ISO3166-2-lvl4"`
+	Postcode      string `json:"postcode"`
+}
+
+// toAddress converte o endereço do Nominatim para o formato dos ecopontos
+func (a nominatimAddress) toAddress(displayName string) *Address {
+	return &Address{
+		Logradouro:  firstNonEmpty(a.Road, a.Pedestrian),
+		Numero:      a.HouseNumber,
+		Bairro:      firstNonEmpty(a.Suburb, a.Neighbourhood, a.CityDistrict),
+		Cidade:      firstNonEmpty(a.City, a.Town, a.Village, a.Municipality),
+		Estado:      toUF(firstNonEmpty(a.StateCode, a.State)),
+		CEP:         a.Postcode,
+		DisplayName: displayName,
+	}
+}
+
+// Nominatim é o Geocoder que usa a API do Nominatim (OpenStreetMap)
+type Nominatim struct {
+	baseURL   string
+	userAgent string
+	client    *Client
+}
+
+// NewNominatim cria o Geocoder do Nominatim. Valores vazios usam os padrões.
+// O client é compartilhado entre os provedores (limite de taxa e circuit breaker do processo).
+func NewNominatim(baseURL, userAgent string, client *Client) *Nominatim {
+	if baseURL == "" {
+		baseURL = DefaultNominatimURL
+	}
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &Nominatim{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		userAgent: userAgent,
+		client:    client,
+	}
+}
+
+// Geocode converte um endereço em coordenadas
+func (n *Nominatim) Geocode(ctx context.Context, address string) (*Result, error) {
+	// 1. Monta a URL
+	query := fmt.Sprintf("/search?q=%s&format=json&limit=1", url.QueryEscape(address))
+
+	// 2. Faz a chamada HTTP
+	var results []NominatimResult
+	if err := n.get(ctx, query, &results); err != nil {
+		return nil, err
+	}
+
+	// 3. Verifica se encontrou algum resultado
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+
+	// 4. Converte as strings Lat/Lon para float64
+	lat, errLat := strconv.ParseFloat(results[0].Lat, 64)
+	lon, errLon := strconv.ParseFloat(results[0].Lon, 64)
+	if errLat != nil || errLon != nil {
+		return nil, errors.New("erro ao converter coordenadas")
+	}
+
+	return &Result{
+		Latitude:  lat,
+		Longitude: lon,
+		Confianca: results[0].Importance,
+		Provider:  ProviderNominatim,
+	}, nil
+}
+
+// Reverse converte coordenadas no endereço mais próximo
+func (n *Nominatim) Reverse(ctx context.Context, lat, lon float64) (*Address, error) {
+	query := fmt.Sprintf("/reverse?lat=%f&lon=%f&format=jsonv2&addressdetails=1", lat, lon)
+
+	var result nominatimReverseResult
+	if err := n.get(ctx, query, &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, ErrNotFound
+	}
+
+	return result.Address.toAddress(result.DisplayName), nil
+}
+
+// Search retorna vários candidatos para o texto, restritos ao Brasil
+func (n *Nominatim) Search(ctx context.Context, query string, opts SearchOptions) ([]Candidate, error) {
+	// 1. Monta a URL
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	params.Set("countrycodes", CountryCode)
+	params.Set("limit", strconv.Itoa(opts.Limite))
+	if opts.Viewbox != nil {
+		params.Set("viewbox", opts.Viewbox.String())
+		params.Set("bounded", "1") // Só resultados dentro da área
+	}
+
+	// 2. Faz a chamada HTTP
+	var results []nominatimSearchResult
+	if err := n.get(ctx, "/search?"+params.Encode(), &results); err != nil {
+		return nil, err
+	}
+
+	// 3. Converte para o formato dos ecopontos
+	candidatos := make([]Candidate, 0, len(results))
+	for _, r := range results {
+		lat, errLat := strconv.ParseFloat(r.Lat, 64)
+		lon, errLon := strconv.ParseFloat(r.Lon, 64)
+		if errLat != nil || errLon != nil {
+			continue
+		}
+		candidatos = append(candidatos, Candidate{
+			DisplayName: r.DisplayName,
+			Endereco:    *r.Address.toAddress(r.DisplayName),
+			Tipo:        r.Type,
+			Importancia: r.Importance,
+			Latitude:    lat,
+			Longitude:   lon,
+		})
+	}
+	return candidatos, nil
+}
+
+// Ping consulta o endpoint /status do Nominatim (responde 200 quando o banco dele está no ar)
+func (n *Nominatim) Ping(ctx context.Context) error {
+	return n.client.ping(ctx, "Nominatim", n.baseURL+"/status?format=json", n.userAgent)
+}
+
+// get faz um GET na API e decodifica o JSON da resposta em out
+func (n *Nominatim) get(ctx context.Context, pathAndQuery string, out any) error {
+	return n.client.getJSON(ctx, "Nominatim", n.baseURL+pathAndQuery, n.userAgent, out)
+}
@@ -0,0 +1,32 @@
+package geocoding
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeAddress gera uma forma canônica do endereço para comparações:
+// sem acentos, em minúsculas, sem pontuação e com espaços simples.
+// Ex.: "Rua  da Aurora, Boa Vista" -> "rua da aurora boa vista"
+func NormalizeAddress(address string) string {
+	// 1. Remove os acentos (decompõe e descarta as marcas diacríticas)
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	semAcento, _, err := transform.String(t, address)
+	if err != nil {
+		semAcento = address
+	}
+
+	// 2. Troca pontuação por espaço e junta os espaços repetidos
+	limpo := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, semAcento)
+
+	return strings.Join(strings.Fields(limpo), " ")
+}
@@ -0,0 +1,167 @@
+package geocoding
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// photonResponse define a estrutura (GeoJSON) da resposta de uma API compatível com o Photon
+type photonResponse struct {
+	Features []photonFeature `json:"features"`
+}
+
+type photonFeature struct {
+	Geometry struct {
+		Coordinates []float64 `json:"coordinates"` // [lon, lat]
+	} `json:"geometry"`
+	Properties photonProperties `json:"properties"`
+}
+
+type photonProperties struct {
+	Name        string `json:"name"`
+	Street      string `json:"street"`
+	HouseNumber string `json:"housenumber"`
+	District    string `json:"district"`
+	Locality    string `json:"locality"`
+	City        string `json:"city"`
+	State       string `json:"state"`
+	Postcode    string `json:"postcode"`
+	CountryCode string `json:"countrycode"`
+	OSMKey      string `json:"osm_key"`
+	OSMValue    string `json:"osm_value"`
+}
+
+// toAddress converte as propriedades do Photon para o formato dos ecopontos
+func (p photonProperties) toAddress() *Address {
+	a := &Address{
+		Logradouro: p.Street,
+		Numero:     p.HouseNumber,
+		Bairro:     firstNonEmpty(p.District, p.Locality),
+		Cidade:     p.City,
+		Estado:     toUF(p.State),
+		CEP:        p.Postcode,
+	}
+	// Quando o ponto é uma rua, o Photon devolve o nome dela em "name"
+	if a.Logradouro == "" && p.OSMKey == "highway" {
+		a.Logradouro = p.Name
+	}
+	a.DisplayName = strings.Join(nonEmpty(p.Name, a.Logradouro, a.Bairro, a.Cidade, a.Estado), ", ")
+	return a
+}
+
+// nonEmpty retorna os valores não vazios, sem repetições consecutivas
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" && (len(out) == 0 || out[len(out)-1] != v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Photon é o Geocoder para uma instância (normalmente self-hosted) compatível com o Photon
+type Photon struct {
+	baseURL   string
+	userAgent string
+	client    *Client
+}
+
+// NewPhoton cria o Geocoder do Photon
+func NewPhoton(baseURL, userAgent string, client *Client) *Photon {
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &Photon{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		userAgent: userAgent,
+		client:    client,
+	}
+}
+
+// Geocode converte um endereço em coordenadas
+func (p *Photon) Geocode(ctx context.Context, address string) (*Result, error) {
+	// 1. Faz a chamada HTTP
+	var resp photonResponse
+	query := fmt.Sprintf("/api?q=%s&limit=1", url.QueryEscape(address))
+	if err := p.get(ctx, query, &resp); err != nil {
+		return nil, err
+	}
+
+	// 2. Verifica se encontrou algum resultado
+	if len(resp.Features) == 0 || len(resp.Features[0].Geometry.Coordinates) < 2 {
+		return nil, ErrNotFound
+	}
+
+	// 3. O GeoJSON guarda as coordenadas como [lon, lat]
+	coords := resp.Features[0].Geometry.Coordinates
+	return &Result{
+		Latitude:  coords[1],
+		Longitude: coords[0],
+		Provider:  ProviderPhoton,
+	}, nil
+}
+
+// Reverse converte coordenadas no endereço mais próximo
+func (p *Photon) Reverse(ctx context.Context, lat, lon float64) (*Address, error) {
+	var resp photonResponse
+	query := fmt.Sprintf("/reverse?lat=%f&lon=%f&limit=1", lat, lon)
+	if err := p.get(ctx, query, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Features) == 0 {
+		return nil, ErrNotFound
+	}
+	return resp.Features[0].Properties.toAddress(), nil
+}
+
+// Search retorna vários candidatos para o texto, restritos ao Brasil.
+// O Photon não filtra por país, então os resultados de fora são descartados aqui.
+func (p *Photon) Search(ctx context.Context, query string, opts SearchOptions) ([]Candidate, error) {
+	// 1. Monta a URL (pede o dobro para compensar os descartados)
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(opts.Limite*2))
+	if opts.Viewbox != nil {
+		params.Set("bbox", opts.Viewbox.String())
+	}
+
+	// 2. Faz a chamada HTTP
+	var resp photonResponse
+	if err := p.get(ctx, "/api?"+params.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	// 3. Converte para o formato dos ecopontos
+	candidatos := make([]Candidate, 0, opts.Limite)
+	for _, f := range resp.Features {
+		if !strings.EqualFold(f.Properties.CountryCode, CountryCode) || len(f.Geometry.Coordinates) < 2 {
+			continue
+		}
+		addr := f.Properties.toAddress()
+		candidatos = append(candidatos, Candidate{
+			DisplayName: addr.DisplayName,
+			Endereco:    *addr,
+			Tipo:        f.Properties.OSMValue,
+			Latitude:    f.Geometry.Coordinates[1],
+			Longitude:   f.Geometry.Coordinates[0],
+		})
+		if len(candidatos) == opts.Limite {
+			break
+		}
+	}
+	return candidatos, nil
+}
+
+// Ping consulta o endpoint /status do Photon
+func (p *Photon) Ping(ctx context.Context) error {
+	return p.client.ping(ctx, "Photon", p.baseURL+"/status", p.userAgent)
+}
+
+// get faz um GET na API e decodifica o JSON da resposta em out
+func (p *Photon) get(ctx context.Context, pathAndQuery string, out any) error {
+	return p.client.getJSON(ctx, "Photon", p.baseURL+pathAndQuery, p.userAgent, out)
+}
@@ -0,0 +1,69 @@
+package geocoding
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// CountryCode restringe as buscas de endereço ao Brasil
+const CountryCode = "br"
+
+// SearchOptions configura a busca de candidatos
+type SearchOptions struct {
+	Limite  int      // Máximo de candidatos retornados
+	Viewbox *Viewbox // Se informado, só retorna candidatos dentro da área (ex.: a cidade)
+}
+
+// Viewbox é uma área retangular em graus
+type Viewbox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// ParseViewbox lê um viewbox no formato "minLon,minLat,maxLon,maxLat"
+func ParseViewbox(s string) (*Viewbox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("viewbox deve ter o formato minLon,minLat,maxLon,maxLat")
+	}
+
+	var v [4]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, errors.New("viewbox contém um número inválido")
+		}
+		v[i] = f
+	}
+
+	vb := &Viewbox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+	if vb.MinLon >= vb.MaxLon || vb.MinLat >= vb.MaxLat ||
+		vb.MinLon < -180 || vb.MaxLon > 180 || vb.MinLat < -90 || vb.MaxLat > 90 {
+		return nil, errors.New("viewbox fora dos limites ou com mínimo maior que o máximo")
+	}
+	return vb, nil
+}
+
+// Contains indica se o ponto está dentro da área
+func (v *Viewbox) Contains(lat, lon float64) bool {
+	return lon >= v.MinLon && lon <= v.MaxLon && lat >= v.MinLat && lat <= v.MaxLat
+}
+
+// String formata o viewbox como "minLon,minLat,maxLon,maxLat"
+func (v *Viewbox) String() string {
+	f := func(x float64) string { return strconv.FormatFloat(x, 'f', -1, 64) }
+	return f(v.MinLon) + "," + f(v.MinLat) + "," + f(v.MaxLon) + "," + f(v.MaxLat)
+}
+
+// Candidate é um resultado da busca de endereços
+type Candidate struct {
+	DisplayName string  `json:"display_name"`
+	Endereco    Address `json:"endereco"`
+	Tipo        string  `json:"tipo"`        // Tipo do lugar no OSM (ex.: residential, bus_stop)
+	Importancia float64 `json:"importancia"` // Relevância informada pelo provedor (0 a 1)
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
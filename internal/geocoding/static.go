@@ -0,0 +1,155 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// staticReverseRadius é a distância máxima (em metros) para o Reverse
+// considerar uma entrada do arquivo como o endereço das coordenadas
+const staticReverseRadius = 200
+
+// StaticEntry é um endereço conhecido no arquivo do Geocoder estático.
+// Os campos estruturados (logradouro, bairro...) são opcionais e usados pelo Reverse.
+type StaticEntry struct {
+	Endereco  string  `json:"endereco"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+
+	Logradouro string `json:"logradouro,omitempty"`
+	Bairro     string `json:"bairro,omitempty"`
+	Cidade     string `json:"cidade,omitempty"`
+	Estado     string `json:"estado,omitempty"`
+	CEP        string `json:"cep,omitempty"`
+}
+
+// Static é um Geocoder falso, lido de um arquivo JSON.
+// Serve para desenvolvimento e testes sem acesso à internet.
+//
+// Formato do arquivo:
+//
+//	[{"endereco": "Rua da Aurora, Boa Vista, Recife, PE", "latitude": -8.06, "longitude": -34.88}]
+type Static struct {
+	entries []StaticEntry
+	index   map[string]StaticEntry // Endereço normalizado -> entrada
+}
+
+// NewStatic carrega o Geocoder estático a partir do arquivo informado
+func NewStatic(path string) (*Static, error) {
+	if path == "" {
+		return nil, errors.New("GEOCODER_STATIC_FILE é obrigatório para o provedor static")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo do geocoder estático: %w", err)
+	}
+
+	var entries []StaticEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar arquivo do geocoder estático: %w", err)
+	}
+
+	return NewStaticFromEntries(entries), nil
+}
+
+// NewStaticFromEntries cria o Geocoder estático a partir de uma lista em memória
+func NewStaticFromEntries(entries []StaticEntry) *Static {
+	s := &Static{
+		entries: entries,
+		index:   make(map[string]StaticEntry, len(entries)),
+	}
+	for _, e := range entries {
+		s.index[NormalizeAddress(e.Endereco)] = e
+	}
+	return s
+}
+
+// Geocode procura o endereço (normalizado) entre as entradas do arquivo
+func (s *Static) Geocode(_ context.Context, address string) (*Result, error) {
+	e, ok := s.index[NormalizeAddress(address)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &Result{
+		Latitude:  e.Latitude,
+		Longitude: e.Longitude,
+		Confianca: 1,
+		Provider:  ProviderStatic,
+	}, nil
+}
+
+// Reverse retorna a entrada do arquivo mais próxima das coordenadas
+// (até staticReverseRadius metros de distância)
+func (s *Static) Reverse(_ context.Context, lat, lon float64) (*Address, error) {
+	var nearest *StaticEntry
+	best := math.Inf(1)
+	for i := range s.entries {
+		d := haversine(lat, lon, s.entries[i].Latitude, s.entries[i].Longitude)
+		if d < best {
+			best, nearest = d, &s.entries[i]
+		}
+	}
+
+	if nearest == nil || best > staticReverseRadius {
+		return nil, ErrNotFound
+	}
+
+	return &Address{
+		Logradouro:  nearest.Logradouro,
+		Bairro:      nearest.Bairro,
+		Cidade:      nearest.Cidade,
+		Estado:      toUF(nearest.Estado),
+		CEP:         nearest.CEP,
+		DisplayName: nearest.Endereco,
+	}, nil
+}
+
+// Search retorna as entradas do arquivo cujo endereço contém o texto buscado
+func (s *Static) Search(_ context.Context, query string, opts SearchOptions) ([]Candidate, error) {
+	termo := NormalizeAddress(query)
+	candidatos := make([]Candidate, 0, opts.Limite)
+
+	for _, e := range s.entries {
+		if !strings.Contains(NormalizeAddress(e.Endereco), termo) {
+			continue
+		}
+		if opts.Viewbox != nil && !opts.Viewbox.Contains(e.Latitude, e.Longitude) {
+			continue
+		}
+		candidatos = append(candidatos, Candidate{
+			DisplayName: e.Endereco,
+			Endereco: Address{
+				Logradouro:  e.Logradouro,
+				Bairro:      e.Bairro,
+				Cidade:      e.Cidade,
+				Estado:      toUF(e.Estado),
+				CEP:         e.CEP,
+				DisplayName: e.Endereco,
+			},
+			Importancia: 1,
+			Latitude:    e.Latitude,
+			Longitude:   e.Longitude,
+		})
+		if len(candidatos) == opts.Limite {
+			break
+		}
+	}
+	return candidatos, nil
+}
+
+// haversine calcula a distância em metros entre dois pontos
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const raioTerra = 6371000 // metros
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * raioTerra * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/database"
+	"github.com/ericoliveiras/ecoponto-api/internal/geocoding"
+	"github.com/jmoiron/sqlx"
+)
+
+// Database verifica a conexão com o banco
+func Database(db *sqlx.DB) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		if err := db.PingContext(ctx); err != nil {
+			return "", err
+		}
+		st := db.Stats()
+		return fmt.Sprintf("%d conexões abertas, %d em uso", st.OpenConnections, st.InUse), nil
+	}
+}
+
+// PostGIS verifica se a extensão PostGIS está instalada (as buscas por proximidade dependem dela)
+func PostGIS(db *sqlx.DB) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		var version string
+		if err := db.GetContext(ctx, &version, `SELECT PostGIS_Lib_Version()`); err != nil {
+			return "", fmt.Errorf("PostGIS indisponível: %w", err)
+		}
+		return "PostGIS " + version, nil
+	}
+}
+
+// Migrations verifica se o banco está na versão de esquema que este binário espera
+func Migrations(db *sqlx.DB) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		latest, err := database.LatestMigration()
+		if err != nil {
+			return "", err
+		}
+		version, dirty, err := database.MigrationStatus(ctx, db)
+		if err != nil {
+			return "", fmt.Errorf("não foi possível ler schema_migrations: %w", err)
+		}
+
+		detalhe := fmt.Sprintf("versão %d de %d", version, latest)
+		switch {
+		case dirty:
+			return detalhe, fmt.Errorf("a migration %d falhou no meio (dirty): corrija e rode de novo", version)
+		case version < latest:
+			return detalhe, fmt.Errorf("%d migration(s) pendente(s)", latest-version)
+		}
+		return detalhe, nil
+	}
+}
+
+// Geocoder verifica se o provedor de geocoding está acessível
+func Geocoder(g geocoding.Geocoder) CheckFunc {
+	return func(ctx context.Context) (string, error) {
+		return "", geocoding.Ping(ctx, g)
+	}
+}
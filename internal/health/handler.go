@@ -0,0 +1,54 @@
+package health
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// Handler gerencia os endpoints de health check
+type Handler struct {
+	db      *sqlx.DB
+	checker *Checker
+}
+
+// NewHandler cria uma nova instância do handler
+func NewHandler(db *sqlx.DB, checker *Checker) *Handler {
+	return &Handler{db: db, checker: checker}
+}
+
+// Healthz é o método para o endpoint GET /healthz (liveness).
+// Só indica que o processo está de pé: não consulta dependências, para o
+// orquestrador não reiniciar a API por causa de uma falha do banco.
+func (h *Handler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Readyz é o método para o endpoint GET /readyz (readiness).
+// Verifica as dependências; responde 503 se alguma verificação crítica falhar.
+func (h *Handler) Readyz(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// Ping é o método para o endpoint GET /ping, mantido por compatibilidade
+func (h *Handler) Ping(c *gin.Context) {
+	if err := h.db.PingContext(c.Request.Context()); err != nil {
+		slog.WarnContext(c.Request.Context(), "Banco de dados não respondeu ao ping", "erro", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"message":   "pong",
+			"db_status": "desconectado",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "pong",
+		"db_status": "conectado",
+	})
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Status de cada verificação e do relatório
+const (
+	StatusOK         = "ok"
+	StatusFalha      = "falha"
+	StatusPronto     = "pronto"
+	StatusNaoPronto  = "nao_pronto"
+	StatusDegradado  = "degradado" // Pronto, mas uma dependência não crítica falhou
+	defaultCacheTime = 30 * time.Second
+)
+
+// CheckFunc executa uma verificação. O detalhe (ex.: versão do PostGIS) vai para o relatório.
+type CheckFunc func(ctx context.Context) (detalhe string, err error)
+
+// Check é uma dependência verificada pelo /readyz
+type Check struct {
+	Nome    string
+	Critico bool // Se falhar, a API não está pronta (503)
+	Run     CheckFunc
+}
+
+// Result é o resultado de uma verificação
+type Result struct {
+	Status     string  `json:"status"`
+	Critico    bool    `json:"critico"`
+	LatenciaMs float64 `json:"latencia_ms"`
+	Detalhe    string  `json:"detalhe,omitempty"`
+	Erro       string  `json:"erro,omitempty"`
+}
+
+// Report é a resposta do /readyz
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready indica se nenhuma verificação crítica falhou
+func (r *Report) Ready() bool {
+	return r.Status != StatusNaoPronto
+}
+
+// Checker executa as verificações em paralelo, cada uma com o seu prazo
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker cria o verificador. timeout é o prazo de cada verificação.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Run executa todas as verificações e monta o relatório
+func (c *Checker) Run(ctx context.Context) *Report {
+	results := make([]Result, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusPronto, Checks: make(map[string]Result, len(c.checks))}
+	for i, check := range c.checks {
+		r := results[i]
+		report.Checks[check.Nome] = r
+		if r.Status == StatusOK {
+			continue
+		}
+		if check.Critico {
+			report.Status = StatusNaoPronto
+		} else if report.Status == StatusPronto {
+			report.Status = StatusDegradado
+		}
+	}
+	return report
+}
+
+// run executa uma verificação, medindo a latência
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	detalhe, err := check.Run(ctx)
+	r := Result{
+		Status:     StatusOK,
+		Critico:    check.Critico,
+		LatenciaMs: float64(time.Since(start).Microseconds()) / 1000,
+		Detalhe:    detalhe,
+	}
+	if err != nil {
+		r.Status = StatusFalha
+		r.Erro = err.Error()
+	}
+	return r
+}
+
+// Cached guarda o resultado da verificação por um tempo, para não chamar
+// serviços externos (ex.: o Nominatim público) a cada probe do orquestrador
+func Cached(ttl time.Duration, fn CheckFunc) CheckFunc {
+	if ttl <= 0 {
+		ttl = defaultCacheTime
+	}
+
+	var (
+		mu      sync.Mutex
+		until   time.Time
+		detalhe string
+		lastErr error
+	)
+	return func(ctx context.Context) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if time.Now().Before(until) {
+			return detalhe, lastErr
+		}
+		detalhe, lastErr = fn(ctx)
+		until = time.Now().Add(ttl)
+		return detalhe, lastErr
+	}
+}
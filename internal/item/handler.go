@@ -0,0 +1,103 @@
+package item
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	limiteBusca     = 20 // Máximo de itens retornados na busca
+	limiteEcopontos = 10 // Máximo de ecopontos sugeridos para um item
+)
+
+// Handler gerencia as requisições HTTP do guia de descarte
+type Handler struct {
+	repo         *Repository
+	ecopontoRepo *ecoponto.Repository
+}
+
+// NewHandler cria uma nova instância do handler
+func NewHandler(repo *Repository, ecopontoRepo *ecoponto.Repository) *Handler {
+	return &Handler{repo: repo, ecopontoRepo: ecopontoRepo}
+}
+
+// SearchItens é o método para o endpoint GET /api/itens?q=
+func (h *Handler) SearchItens(c *gin.Context) {
+	// 1. Ler o termo de busca
+	termo := strings.TrimSpace(c.Query("q"))
+	if termo == "" {
+		apierror.Respond(c, http.StatusBadRequest, "Parâmetro 'q' é obrigatório")
+		return
+	}
+
+	// 2. Chamar o repositório
+	itens, err := h.repo.Search(c.Request.Context(), termo, limiteBusca)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 3. Retornar os itens encontrados (mesmo que a lista esteja vazia)
+	c.JSON(http.StatusOK, itens)
+}
+
+// GetDescarte é o método para o endpoint GET /api/itens/:id/descarte
+// Retorna as instruções do item e os ecopontos mais próximos que o aceitam
+func (h *Handler) GetDescarte(c *gin.Context) {
+	// 1. Ler e validar o id do item e os parâmetros de proximidade
+	id, ok := itemIDParam(c)
+	if !ok {
+		return
+	}
+	params, err := ecoponto.ParseListByProximityParams(c)
+	if err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Buscar o item
+	it, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Item não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 3. Reutiliza a busca por proximidade filtrando pelo tipo de resíduo do item
+	params.TipoResiduo = it.TipoResiduo
+	params.Limite = limiteEcopontos
+
+	pontos, err := h.ecopontoRepo.ListByProximity(c.Request.Context(), tenant.IDFromContext(c), params)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Retornar as instruções junto com os ecopontos
+	c.JSON(http.StatusOK, DescarteResponse{
+		Item:      *it,
+		Ecopontos: pontos,
+	})
+}
+
+// itemIDParam lê o :id da rota; um id que não é UUID não existe no catálogo
+// e responde 404 sem chegar ao banco
+func itemIDParam(c *gin.Context) (string, bool) {
+	var uri struct {
+		ID string `uri:"id" binding:"uuid"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		apierror.Respond(c, http.StatusNotFound, "Item não encontrado")
+		return "", false
+	}
+	return uri.ID, true
+}
@@ -0,0 +1,64 @@
+package item
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestRouter monta as rotas do guia de descarte sobre o banco falso
+func newTestRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("consultas esperadas não executadas: %v", err)
+		}
+		db.Close()
+	})
+
+	dbx := sqlx.NewDb(db, "postgres")
+	h := NewHandler(NewRepository(dbx), ecoponto.NewRepository(dbx))
+	r := gin.New()
+	r.GET("/api/itens", h.SearchItens)
+	r.GET("/api/itens/:id/descarte", h.GetDescarte)
+	return r, mock
+}
+
+func TestSearchItensEscapesLikeWildcards(t *testing.T) {
+	r, mock := newTestRouter(t)
+
+	// "%" e "_" na busca são texto, não curingas
+	mock.ExpectQuery(`LIKE '%' \|\| unaccent\(lower\(\$3\)\) \|\| '%' ESCAPE '\\'`).
+		WithArgs(`pilha_aa%`, limiteBusca, `pilha\_aa\%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/itens?q=pilha_aa%25", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, corpo %s", w.Code, w.Body)
+	}
+}
+
+func TestGetDescarteRejectsMalformedID(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	// Um id que não é UUID não chega ao banco: 404, não 500
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/itens/abc/descarte?lat=-8.06&lon=-34.88", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status %d, esperado 404 (corpo %s)", w.Code, w.Body)
+	}
+}
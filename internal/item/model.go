@@ -0,0 +1,26 @@
+package item
+
+import (
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/lib/pq"
+)
+
+// Item representa um objeto do dia a dia (ex.: "pilha", "sofá") e
+// o tipo de resíduo em que ele se enquadra
+type Item struct {
+	ID          string         `db:"id" json:"id"`
+	Nome        string         `db:"nome" json:"nome"`
+	Sinonimos   pq.StringArray `db:"sinonimos" json:"sinonimos"`
+	TipoResiduo string         `db:"tipo_residuo" json:"tipo_residuo"`
+	Instrucoes  string         `db:"instrucoes" json:"instrucoes"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+}
+
+// DescarteResponse junta as instruções de descarte de um item
+// com os ecopontos mais próximos que o aceitam
+type DescarteResponse struct {
+	Item      Item                `json:"item"`
+	Ecopontos []ecoponto.EcoPonto `json:"ecopontos"`
+}
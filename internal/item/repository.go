@@ -0,0 +1,72 @@
+package item
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// likeEscaper escapa os curingas do LIKE (\ é o escape padrão do Postgres),
+// para a busca tratar "%" e "_" como texto
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Repository gerencia a persistência do catálogo de itens
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository cria uma nova instância do repositório
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Search busca itens pelo nome ou sinônimos, ignorando acentos e maiúsculas.
+// Correspondências exatas aparecem primeiro, seguidas das que começam pelo termo.
+// "%" e "_" no termo são texto, não curingas.
+func (r *Repository) Search(ctx context.Context, termo string, limite int) ([]Item, error) {
+	query := `
+		SELECT
+			id, nome, sinonimos, tipo_residuo, instrucoes, created_at
+		FROM
+			itens
+		WHERE
+			unaccent(lower(nome)) LIKE '%' || unaccent(lower($3)) || '%' ESCAPE '\'
+			OR EXISTS (
+				SELECT 1 FROM unnest(sinonimos) AS s
+				WHERE unaccent(lower(s)) LIKE '%' || unaccent(lower($3)) || '%' ESCAPE '\'
+			)
+		ORDER BY
+			unaccent(lower(nome)) = unaccent(lower($1)) DESC,
+			unaccent(lower(nome)) LIKE unaccent(lower($3)) || '%' ESCAPE '\' DESC,
+			nome
+		LIMIT $2
+	`
+	var itens []Item
+	err := r.db.SelectContext(ctx, &itens, query, termo, limite, likeEscaper.Replace(termo))
+	if err != nil {
+		return nil, err
+	}
+	if itens == nil {
+		itens = make([]Item, 0)
+	}
+	return itens, nil
+}
+
+// GetByID busca um item do catálogo pelo seu ID
+func (r *Repository) GetByID(ctx context.Context, id string) (*Item, error) {
+	query := `
+		SELECT
+			id, nome, sinonimos, tipo_residuo, instrucoes, created_at
+		FROM
+			itens
+		WHERE
+			id = $1
+	`
+	var it Item
+	err := r.db.GetContext(ctx, &it, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &it, nil
+}
@@ -0,0 +1,91 @@
+package job
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/auth"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler gerencia as requisições HTTP relacionadas aos jobs
+type Handler struct {
+	repo *Repository
+}
+
+// NewHandler cria uma nova instância do handler
+func NewHandler(repo *Repository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// CreateImportacao é o método para o endpoint POST /api/ecopontos/importacao.
+// Os ecopontos são validados agora, mas geocodificados e inseridos em segundo plano.
+func (h *Handler) CreateImportacao(c *gin.Context) {
+	// 1. Faz o "bind" do JSON (valida cada ecoponto do lote)
+	var req ImportacaoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Cada ecoponto vira um item do job
+	payloads := make([]json.RawMessage, len(req.Ecopontos))
+	for i, e := range req.Ecopontos {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			apierror.Internal(c, err)
+			return
+		}
+		payloads[i] = payload
+	}
+
+	var createdBy *string
+	if userID := auth.UserIDFromContext(c); userID != "" {
+		createdBy = &userID
+	}
+
+	// 3. Grava o job; os workers o pegam a partir daqui
+	j, err := h.repo.Create(c.Request.Context(), tenant.IDFromContext(c), TipoImportacao, createdBy, payloads)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Retorna 202: o progresso é acompanhado em GET /api/jobs/:id
+	c.JSON(http.StatusAccepted, j)
+}
+
+// GetJob é o método para o endpoint GET /api/jobs/:id
+func (h *Handler) GetJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 1. Busca o job (apenas do município atual)
+	j, err := h.repo.GetByID(ctx, tenant.IDFromContext(c), c.Param("id"))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Job não encontrado")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 2. Conta os itens por status
+	contagem, err := h.repo.CountByStatus(ctx, j.ID)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 3. Lista os itens que precisam de revisão manual
+	revisao, err := h.repo.ListByStatus(ctx, j.ID, StatusRevisaoManual)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, StatusResponse{Job: *j, Contagem: contagem, RevisaoManual: revisao})
+}
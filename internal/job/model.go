@@ -0,0 +1,65 @@
+package job
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+)
+
+// Tipos de job
+const (
+	TipoImportacao = "importacao" // Geocodifica e insere ecopontos em lote
+)
+
+// Status de um job
+const (
+	StatusPendente    = "pendente"
+	StatusProcessando = "processando"
+	StatusConcluido   = "concluido"
+)
+
+// Status de um item do job (além dos três acima)
+const (
+	StatusRevisaoManual = "revisao_manual" // Não foi possível processar; precisa de um admin
+)
+
+// Job é um processamento em segundo plano, dividido em itens
+type Job struct {
+	ID         string     `db:"id" json:"id"`
+	TenantID   string     `db:"tenant_id" json:"-"`
+	Tipo       string     `db:"tipo" json:"tipo"`
+	Status     string     `db:"status" json:"status"`
+	Total      int        `db:"total" json:"total"`
+	CreatedBy  *string    `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+}
+
+// Item é uma unidade de trabalho do job (ex.: um ecoponto da importação)
+type Item struct {
+	ID                 string          `db:"id" json:"id"`
+	JobID              string          `db:"job_id" json:"-"`
+	TenantID           string          `db:"tenant_id" json:"-"`
+	Posicao            int             `db:"posicao" json:"posicao"` // Índice no lote enviado
+	Payload            json.RawMessage `db:"payload" json:"payload"`
+	Status             string          `db:"status" json:"status"`
+	Tentativas         int             `db:"tentativas" json:"tentativas"`
+	ProximaTentativaEm time.Time       `db:"proxima_tentativa_em" json:"-"`
+	Erro               *string         `db:"erro" json:"erro,omitempty"`
+	EcopontoID         *string         `db:"ecoponto_id" json:"ecoponto_id,omitempty"`
+	UpdatedAt          time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// StatusResponse é a resposta de GET /api/jobs/:id
+type StatusResponse struct {
+	Job
+	Contagem      map[string]int `json:"contagem"`       // Itens por status
+	RevisaoManual []Item         `json:"revisao_manual"` // Itens que precisam de um admin
+}
+
+// ImportacaoRequest é o corpo de POST /api/ecopontos/importacao
+type ImportacaoRequest struct {
+	Ecopontos []ecoponto.CreateEcoPontoRequest `json:"ecopontos" binding:"required,min=1,max=1000,dive"`
+}
@@ -0,0 +1,219 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// staleAfter é o tempo após o qual um item "processando" é considerado abandonado
+// (ex.: o processo caiu no meio do processamento) e volta a ser elegível
+const staleAfter = 5 * time.Minute
+
+const jobColumns = `id, tenant_id, tipo, status, total, created_by, created_at, updated_at, finished_at`
+
+const itemColumns = `id, job_id, posicao, payload, status, tentativas, proxima_tentativa_em, erro, ecoponto_id, updated_at`
+
+// Repository gerencia a persistência dos jobs e dos seus itens
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository cria uma nova instância do repositório
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create cria um job e todos os seus itens numa única transação
+func (r *Repository) Create(ctx context.Context, tenantID, tipo string, createdBy *string, payloads []json.RawMessage) (*Job, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Cria o job
+	var j Job
+	err = tx.QueryRowxContext(ctx, `
+		INSERT INTO jobs (tenant_id, tipo, total, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+jobColumns,
+		tenantID, tipo, len(payloads), createdBy,
+	).StructScan(&j)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Cria os itens, guardando a posição de cada um no lote
+	stmt, err := tx.PreparexContext(ctx, `INSERT INTO job_itens (job_id, posicao, payload) VALUES ($1, $2, $3)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for i, payload := range payloads {
+		if _, err := stmt.ExecContext(ctx, j.ID, i, []byte(payload)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// GetByID busca um job do município
+func (r *Repository) GetByID(ctx context.Context, tenantID, id string) (*Job, error) {
+	var j Job
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1 AND tenant_id = $2`
+	if err := r.db.GetContext(ctx, &j, query, id, tenantID); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// CountByStatus conta os itens do job agrupados por status
+func (r *Repository) CountByStatus(ctx context.Context, jobID string) (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Total  int    `db:"total"`
+	}
+	query := `SELECT status, COUNT(*) AS total FROM job_itens WHERE job_id = $1 GROUP BY status`
+	if err := r.db.SelectContext(ctx, &rows, query, jobID); err != nil {
+		return nil, err
+	}
+
+	contagem := map[string]int{
+		StatusPendente:      0,
+		StatusProcessando:   0,
+		StatusConcluido:     0,
+		StatusRevisaoManual: 0,
+	}
+	for _, row := range rows {
+		contagem[row.Status] = row.Total
+	}
+	return contagem, nil
+}
+
+// CountQueue conta os itens ainda não concluídos de todos os jobs, por status (tamanho da fila)
+func (r *Repository) CountQueue(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		Status string `db:"status"`
+		Total  int    `db:"total"`
+	}
+	query := `SELECT status, COUNT(*) AS total FROM job_itens WHERE status <> $1 GROUP BY status`
+	if err := r.db.SelectContext(ctx, &rows, query, StatusConcluido); err != nil {
+		return nil, err
+	}
+
+	contagem := map[string]int{
+		StatusPendente:      0,
+		StatusProcessando:   0,
+		StatusRevisaoManual: 0,
+	}
+	for _, row := range rows {
+		contagem[row.Status] = row.Total
+	}
+	return contagem, nil
+}
+
+// ListByStatus lista os itens do job com o status informado, na ordem do lote
+func (r *Repository) ListByStatus(ctx context.Context, jobID, status string) ([]Item, error) {
+	var itens []Item
+	query := `SELECT ` + itemColumns + ` FROM job_itens WHERE job_id = $1 AND status = $2 ORDER BY posicao`
+	if err := r.db.SelectContext(ctx, &itens, query, jobID, status); err != nil {
+		return nil, err
+	}
+	if itens == nil {
+		itens = make([]Item, 0)
+	}
+	return itens, nil
+}
+
+// Claim reserva o próximo item elegível para processamento.
+// FOR UPDATE SKIP LOCKED garante que dois workers (ou duas instâncias da API)
+// nunca peguem o mesmo item. Retorna sql.ErrNoRows se não houver trabalho.
+func (r *Repository) Claim(ctx context.Context) (*Item, error) {
+	query := `
+		UPDATE job_itens ji
+		SET
+			status = 'processando',
+			tentativas = ji.tentativas + 1,
+			updated_at = NOW()
+		FROM jobs j
+		WHERE
+			j.id = ji.job_id
+			AND ji.id = (
+				SELECT id FROM job_itens
+				WHERE
+					(status = 'pendente' AND proxima_tentativa_em <= NOW())
+					OR (status = 'processando' AND updated_at < NOW() - make_interval(secs => $1))
+				ORDER BY proxima_tentativa_em
+				LIMIT 1
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING
+			ji.id, ji.job_id, j.tenant_id, ji.posicao, ji.payload, ji.status, ji.tentativas,
+			ji.proxima_tentativa_em, ji.erro, ji.ecoponto_id, ji.updated_at
+	`
+	var it Item
+	if err := r.db.QueryRowxContext(ctx, query, staleAfter.Seconds()).StructScan(&it); err != nil {
+		return nil, err
+	}
+
+	// Marca o job como em andamento (só muda na primeira vez)
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = 'processando', updated_at = NOW()
+		WHERE id = $1 AND status = 'pendente'`, it.JobID)
+	if err != nil {
+		return nil, err
+	}
+	return &it, nil
+}
+
+// Complete marca o item como concluído, guardando o ecoponto criado
+func (r *Repository) Complete(ctx context.Context, it *Item, ecopontoID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE job_itens SET status = 'concluido', ecoponto_id = $2, erro = NULL, updated_at = NOW()
+		WHERE id = $1`, it.ID, ecopontoID)
+	if err != nil {
+		return err
+	}
+	return r.finishIfDone(ctx, it.JobID)
+}
+
+// Retry devolve o item para a fila, para uma nova tentativa após o atraso
+func (r *Repository) Retry(ctx context.Context, it *Item, erro string, delay time.Duration) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE job_itens
+		SET status = 'pendente', erro = $2, proxima_tentativa_em = NOW() + make_interval(secs => $3), updated_at = NOW()
+		WHERE id = $1`, it.ID, erro, delay.Seconds())
+	return err
+}
+
+// MarkForReview tira o item da fila, marcando-o para revisão manual
+func (r *Repository) MarkForReview(ctx context.Context, it *Item, erro string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE job_itens SET status = 'revisao_manual', erro = $2, updated_at = NOW()
+		WHERE id = $1`, it.ID, erro)
+	if err != nil {
+		return err
+	}
+	return r.finishIfDone(ctx, it.JobID)
+}
+
+// finishIfDone conclui o job quando não resta nenhum item pendente ou em processamento
+func (r *Repository) finishIfDone(ctx context.Context, jobID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE jobs SET status = 'concluido', finished_at = NOW(), updated_at = NOW()
+		WHERE
+			id = $1
+			AND status <> 'concluido'
+			AND NOT EXISTS (
+				SELECT 1 FROM job_itens
+				WHERE job_id = $1 AND status IN ('pendente', 'processando')
+			)`, jobID)
+	return err
+}
@@ -0,0 +1,217 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/database"
+	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/ericoliveiras/ecoponto-api/internal/geocoding"
+	"github.com/ericoliveiras/ecoponto-api/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WorkerOptions configura o pool de workers
+type WorkerOptions struct {
+	Workers       int           // Quantidade de goroutines processando itens
+	PollInterval  time.Duration // Espera quando a fila está vazia
+	MaxTentativas int           // Tentativas até mandar o item para revisão manual
+	BackoffBase   time.Duration // Espera antes da 2ª tentativa (dobra a cada tentativa)
+}
+
+// maxBackoff limita a espera entre tentativas de um item
+const maxBackoff = time.Hour
+
+// resultTimeout é o prazo para gravar o resultado de um item, mesmo no desligamento
+const resultTimeout = 10 * time.Second
+
+// Worker processa os itens dos jobs em segundo plano.
+// O ritmo das chamadas ao provedor é controlado pelo cliente de geocoding
+// (limite de taxa e circuit breaker), então vários workers podem rodar juntos.
+type Worker struct {
+	repo         *Repository
+	ecopontoRepo *ecoponto.Repository
+	geocoder     geocoding.Geocoder
+	opts         WorkerOptions
+
+	stop     chan struct{} // Fechado por Stop: não pega mais itens
+	stopOnce sync.Once
+}
+
+// NewWorker cria um novo pool de workers
+func NewWorker(repo *Repository, ecopontoRepo *ecoponto.Repository, geocoder geocoding.Geocoder, opts WorkerOptions) *Worker {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+	if opts.MaxTentativas <= 0 {
+		opts.MaxTentativas = 5
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = 30 * time.Second
+	}
+	return &Worker{repo: repo, ecopontoRepo: ecopontoRepo, geocoder: geocoder, opts: opts, stop: make(chan struct{})}
+}
+
+// Run inicia os workers e bloqueia até todos pararem. Depois de Stop, cada worker
+// termina o item em andamento e para; cancelar o contexto interrompe o item,
+// que volta para a fila.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// Stop pede aos workers que não peguem mais itens (o item em andamento é concluído)
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// stopping informa se o worker deve parar
+func (w *Worker) stopping(ctx context.Context) bool {
+	select {
+	case <-w.stop:
+		return true
+	default:
+		return ctx.Err() != nil
+	}
+}
+
+// loop pega itens da fila até Stop ou o cancelamento do contexto
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		if w.stopping(ctx) {
+			return
+		}
+
+		// 1. Reserva o próximo item
+		it, err := w.repo.Claim(ctx)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Erro ao buscar item da fila de jobs", "erro", err)
+			}
+			// 2. Fila vazia (ou banco indisponível): espera antes de tentar de novo
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.stop:
+				return
+			case <-time.After(w.opts.PollInterval):
+			}
+			continue
+		}
+
+		// 3. Processa o item. Se o processo morrer no meio, o item fica
+		// "processando" e é retomado por outro worker depois (ver staleAfter)
+		w.process(ctx, it)
+	}
+}
+
+// process executa um item e registra o resultado
+func (w *Worker) process(ctx context.Context, it *Item) {
+	// Um trace por item: o geocoding e as consultas ao banco ficam dentro dele
+	ctx, span := tracing.Tracer().Start(ctx, "job.item", trace.WithAttributes(
+		attribute.String("job.id", it.JobID),
+		attribute.String("job.item.id", it.ID),
+		attribute.Int("job.item.tentativa", it.Tentativas),
+	))
+	defer span.End()
+
+	ecopontoID, err := w.importEcoponto(ctx, it)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	// O resultado é gravado mesmo que o contexto do worker já tenha sido cancelado:
+	// o ecoponto pode ter sido inserido, e o item não deve ficar "processando"
+	resultCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), resultTimeout)
+	defer cancel()
+
+	var result error
+	switch {
+	case err == nil:
+		result = w.repo.Complete(resultCtx, it, ecopontoID)
+	case ctx.Err() != nil:
+		// Interrompido no desligamento: volta para a fila já, sem esperar o staleAfter
+		result = w.repo.Retry(resultCtx, it, "interrompido no desligamento", 0)
+	case errors.Is(err, errRevisao):
+		result = w.repo.MarkForReview(resultCtx, it, err.Error())
+	case it.Tentativas >= w.opts.MaxTentativas:
+		result = w.repo.MarkForReview(resultCtx, it, fmt.Sprintf("desistindo após %d tentativas: %v", it.Tentativas, err))
+	default:
+		result = w.repo.Retry(resultCtx, it, err.Error(), w.backoff(it.Tentativas))
+	}
+	if result != nil {
+		slog.ErrorContext(ctx, "Erro ao registrar o resultado do item", "erro", result, "job_id", it.JobID, "item_id", it.ID)
+	}
+}
+
+// errRevisao marca erros que não se resolvem com uma nova tentativa
+var errRevisao = errors.New("revisão manual necessária")
+
+// revisao embrulha a mensagem num erro definitivo (sem nova tentativa)
+func revisao(msg string) error {
+	return fmt.Errorf("%w: %s", errRevisao, msg)
+}
+
+// importEcoponto geocodifica (se preciso) e insere o ecoponto do item
+func (w *Worker) importEcoponto(ctx context.Context, it *Item) (string, error) {
+	// 1. Lê o ecoponto enviado no lote (já validado na criação do job)
+	var req ecoponto.CreateEcoPontoRequest
+	if err := json.Unmarshal(it.Payload, &req); err != nil {
+		return "", revisao("conteúdo do item inválido")
+	}
+
+	// 2. Usa as coordenadas enviadas ou geocodifica o endereço
+	var lat, lon float64
+	if req.Latitude != nil && req.Longitude != nil {
+		lat, lon = *req.Latitude, *req.Longitude
+	} else {
+		address := fmt.Sprintf("%s, %s, %s, %s", req.Logradouro, req.Bairro, req.Cidade, req.Estado)
+		res, err := w.geocoder.Geocode(ctx, address)
+		if err != nil {
+			if errors.Is(err, geocoding.ErrNotFound) {
+				return "", revisao("Endereço não encontrado ou inválido")
+			}
+			return "", err
+		}
+		lat, lon = res.Latitude, res.Longitude
+	}
+
+	// 3. Insere o ecoponto no município do job (idempotente: uma nova tentativa
+	// do mesmo item reaproveita o ecoponto já inserido)
+	ponto, err := w.ecopontoRepo.CreateFromJobItem(ctx, it.TenantID, it.ID, req, lat, lon)
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return "", revisao("Organização não encontrada")
+		}
+		return "", err
+	}
+	return ponto.ID, nil
+}
+
+// backoff calcula a espera antes da próxima tentativa (exponencial, com teto)
+func (w *Worker) backoff(tentativas int) time.Duration {
+	d := w.opts.BackoffBase
+	for i := 1; i < tentativas && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LogSender "envia" os e-mails escrevendo-os no log (desenvolvimento local)
+type LogSender struct {
+	from string
+}
+
+// NewLogSender cria um Sender que escreve os e-mails no log
+func NewLogSender(from string) *LogSender {
+	return &LogSender{from: from}
+}
+
+// Send escreve o e-mail no log
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "E-mail (não enviado, driver log)",
+		"from", s.from,
+		"to", msg.To,
+		"subject", msg.Subject,
+		"body", msg.Body,
+	)
+	return nil
+}
+
+// FileSender grava cada e-mail num arquivo .eml da pasta configurada,
+// que pode ser aberto por qualquer cliente de e-mail
+type FileSender struct {
+	from string
+	dir  string
+}
+
+// NewFileSender cria um Sender que grava os e-mails em arquivos
+func NewFileSender(from, dir string) *FileSender {
+	return &FileSender{from: from, dir: dir}
+}
+
+// Send grava o e-mail em <dir>/<timestamp>-<destinatário>.eml
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), filepath.Base(msg.To))
+	return os.WriteFile(filepath.Join(s.dir, name), buildMessage(s.from, msg), 0o644)
+}
@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	netmail "net/mail"
+	"time"
+)
+
+// Message é um e-mail em texto puro
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender envia e-mails. Há implementações para SMTP (produção)
+// e para log/arquivo (desenvolvimento local, sem servidor de e-mail).
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Drivers de envio
+const (
+	DriverLog  = "log"
+	DriverFile = "file"
+	DriverSMTP = "smtp"
+)
+
+// Options configura o Sender criado por New
+type Options struct {
+	Driver string // smtp, ou log/file em desenvolvimento
+	From   string // Remetente (ex.: "EcoPonto <nao-responda@seu-dominio.gov.br>")
+	Dir    string // Pasta onde o driver file grava os e-mails
+	Dev    bool   // Modo de desenvolvimento: libera os drivers log e file
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUser     string
+	SMTPPassword string
+	SMTPTimeout  time.Duration
+}
+
+// New cria o Sender do driver configurado. Os drivers log e file gravam os e-mails
+// (com os links de redefinição de senha) em claro, então só são aceitos no modo
+// de desenvolvimento; sem driver, a API não sobe.
+func New(opts Options) (Sender, error) {
+	if (opts.Driver == DriverLog || opts.Driver == DriverFile) && !opts.Dev {
+		return nil, fmt.Errorf("o driver de e-mail %q grava os links de redefinição de senha em claro: use smtp ou defina MAIL_DEV=true", opts.Driver)
+	}
+
+	switch opts.Driver {
+	case "":
+		return nil, fmt.Errorf("MAIL_DRIVER é obrigatória: %q (ou %q/%q com MAIL_DEV=true)", DriverSMTP, DriverLog, DriverFile)
+	case DriverLog:
+		return NewLogSender(opts.From), nil
+	case DriverFile:
+		if opts.Dir == "" {
+			return nil, fmt.Errorf("MAIL_DIR é obrigatória para o driver %q", DriverFile)
+		}
+		return NewFileSender(opts.From, opts.Dir), nil
+	case DriverSMTP:
+		if opts.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST é obrigatória para o driver %q", DriverSMTP)
+		}
+		return NewSMTPSender(opts.SMTPHost, opts.SMTPPort, opts.SMTPUser, opts.SMTPPassword, opts.From, opts.SMTPTimeout), nil
+	default:
+		return nil, fmt.Errorf("driver de e-mail desconhecido: %q", opts.Driver)
+	}
+}
+
+// mailAddress extrai o endereço de um remetente como "Nome <email>"
+func mailAddress(from string) (string, error) {
+	addr, err := netmail.ParseAddress(from)
+	if err != nil {
+		return "", fmt.Errorf("remetente inválido %q: %w", from, err)
+	}
+	return addr.Address, nil
+}
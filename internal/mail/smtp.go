@@ -0,0 +1,110 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPSender envia os e-mails por um servidor SMTP, usando STARTTLS quando disponível
+type SMTPSender struct {
+	host     string
+	port     int
+	user     string
+	password string
+	from     string
+	timeout  time.Duration
+}
+
+// NewSMTPSender cria um Sender SMTP. Sem usuário, envia sem autenticação.
+func NewSMTPSender(host string, port int, user, password, from string, timeout time.Duration) *SMTPSender {
+	if port == 0 {
+		port = 587
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &SMTPSender{host: host, port: port, user: user, password: password, from: from, timeout: timeout}
+}
+
+// Send envia o e-mail, respeitando o prazo do contexto
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	// 1. Conecta respeitando o contexto e o timeout configurado
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar ao SMTP: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("erro ao iniciar SMTP: %w", err)
+	}
+	defer c.Close()
+
+	// 2. Criptografa a conexão, se o servidor suportar
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("erro no STARTTLS: %w", err)
+		}
+	}
+
+	// 3. Autentica (smtp.PlainAuth recusa enviar a senha sem TLS, exceto para localhost)
+	if s.user != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+			return fmt.Errorf("erro na autenticação SMTP: %w", err)
+		}
+	}
+
+	// 4. Envia a mensagem
+	from, err := mailAddress(s.from)
+	if err != nil {
+		return err
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(s.from, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage monta o e-mail no formato RFC 5322 (texto puro, UTF-8)
+func buildMessage(from string, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+	return b.Bytes()
+}
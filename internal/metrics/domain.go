@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/ericoliveiras/ecoponto-api/internal/job"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// domainTimeout é o prazo das consultas feitas a cada coleta do Prometheus
+const domainTimeout = 5 * time.Second
+
+var (
+	ecopontosDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "ecopontos"),
+		"Ecopontos cadastrados, por município e tipo de resíduo.",
+		[]string{"municipio", "tipo_residuo"}, nil,
+	)
+	jobItensDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "job_itens"),
+		"Itens de importação ainda não concluídos, por status.",
+		[]string{"status"}, nil,
+	)
+)
+
+// domainCollector consulta o banco a cada coleta, para os números refletirem
+// também o que outras instâncias da API gravaram
+type domainCollector struct {
+	ecopontos *ecoponto.Repository
+	jobs      *job.Repository
+}
+
+// NewDomainCollector cria o coletor das métricas de domínio
+func NewDomainCollector(ecopontos *ecoponto.Repository, jobs *job.Repository) prometheus.Collector {
+	return &domainCollector{ecopontos: ecopontos, jobs: jobs}
+}
+
+// Describe envia as descrições das métricas
+func (d *domainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ecopontosDesc
+	ch <- jobItensDesc
+}
+
+// Collect consulta o banco e envia os valores atuais
+func (d *domainCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), domainTimeout)
+	defer cancel()
+
+	// 1. Ecopontos por município e tipo de resíduo
+	contagens, err := d.ecopontos.CountByTipo(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(ecopontosDesc, err)
+	}
+	for _, c := range contagens {
+		ch <- prometheus.MustNewConstMetric(ecopontosDesc, prometheus.GaugeValue, float64(c.Total), c.Municipio, c.TipoResiduo)
+	}
+
+	// 2. Fila de importação
+	fila, err := d.jobs.CountQueue(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(jobItensDesc, err)
+		return
+	}
+	for status, total := range fila {
+		ch <- prometheus.MustNewConstMetric(jobItensDesc, prometheus.GaugeValue, float64(total), status)
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/geocoding"
+)
+
+// instrumentedGeocoder mede as chamadas ao provedor de geocoding
+type instrumentedGeocoder struct {
+	next     geocoding.Geocoder
+	provider string
+	m        *Metrics
+}
+
+// InstrumentGeocoder embrulha o provedor para contar e cronometrar as chamadas.
+// Deve ficar por dentro do cache, para medir só o que de fato vai ao provedor.
+func (m *Metrics) InstrumentGeocoder(next geocoding.Geocoder, provider string) geocoding.Geocoder {
+	if provider == "" {
+		provider = geocoding.ProviderNominatim
+	}
+	return &instrumentedGeocoder{next: next, provider: provider, m: m}
+}
+
+// Geocode converte um endereço em coordenadas
+func (g *instrumentedGeocoder) Geocode(ctx context.Context, address string) (*geocoding.Result, error) {
+	start := time.Now()
+	res, err := g.next.Geocode(ctx, address)
+	g.observe("geocode", start, err)
+	return res, err
+}
+
+// Reverse converte coordenadas no endereço mais próximo
+func (g *instrumentedGeocoder) Reverse(ctx context.Context, lat, lon float64) (*geocoding.Address, error) {
+	start := time.Now()
+	addr, err := g.next.Reverse(ctx, lat, lon)
+	g.observe("reverse", start, err)
+	return addr, err
+}
+
+// Search retorna vários candidatos para o texto
+func (g *instrumentedGeocoder) Search(ctx context.Context, query string, opts geocoding.SearchOptions) ([]geocoding.Candidate, error) {
+	start := time.Now()
+	candidatos, err := g.next.Search(ctx, query, opts)
+	g.observe("search", start, err)
+	return candidatos, err
+}
+
+// Ping verifica o provedor (não entra nas métricas)
+func (g *instrumentedGeocoder) Ping(ctx context.Context) error {
+	return geocoding.Ping(ctx, g.next)
+}
+
+// observe registra o resultado e a duração de uma chamada
+func (g *instrumentedGeocoder) observe(operacao string, start time.Time, err error) {
+	g.m.geocoderDuration.WithLabelValues(g.provider, operacao).Observe(time.Since(start).Seconds())
+	g.m.geocoderCalls.WithLabelValues(g.provider, operacao, resultado(err)).Inc()
+}
+
+// resultado classifica o erro de uma chamada ao provedor
+func resultado(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, geocoding.ErrNotFound):
+		return "nao_encontrado"
+	case errors.Is(err, geocoding.ErrCircuitOpen):
+		return "circuito_aberto"
+	default:
+		return "erro"
+	}
+}
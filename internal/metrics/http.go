@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rotaDesconhecida agrupa as requisições que não casaram com nenhuma rota (404),
+// para URLs arbitrárias não criarem uma série nova cada
+const rotaDesconhecida = "desconhecida"
+
+// Middleware cria um middleware Gin que conta e cronometra as requisições.
+// A rota é o padrão registrado (ex.: /api/ecopontos/:id), não a URL.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.httpInFlight.Inc()
+		defer m.httpInFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = rotaDesconhecida
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		m.httpRequests.WithLabelValues(c.Request.Method, route, status).Inc()
+		m.httpDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace é o prefixo de todas as métricas da API
+const namespace = "ecoponto"
+
+// Metrics reúne o registro do Prometheus e as métricas coletadas pela API
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// HTTP
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+
+	// Geocoding (chamadas ao provedor, depois do cache)
+	geocoderCalls    *prometheus.CounterVec
+	geocoderDuration *prometheus.HistogramVec
+}
+
+// New cria as métricas e o registro, já com as métricas do runtime Go e do processo
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Requisições HTTP atendidas, por método, rota e status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Tempo de resposta das requisições HTTP, por método, rota e status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Requisições HTTP em andamento.",
+		}),
+
+		geocoderCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "geocoder_calls_total",
+			Help:      "Chamadas ao provedor de geocoding, por operação e resultado (ok, nao_encontrado, circuito_aberto, erro).",
+		}, []string{"provider", "operacao", "resultado"}),
+		geocoderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "geocoder_call_duration_seconds",
+			Help:      "Tempo das chamadas ao provedor de geocoding (com repetições e espera no limite de taxa).",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"provider", "operacao"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.geocoderCalls,
+		m.geocoderDuration,
+	)
+	return m
+}
+
+// RegisterDB expõe as estatísticas do pool de conexões (sql.DBStats)
+func (m *Metrics) RegisterDB(db *sqlx.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db.DB, "ecoponto"))
+}
+
+// Register expõe coletores adicionais (ex.: o de métricas de domínio)
+func (m *Metrics) Register(c prometheus.Collector) {
+	m.registry.MustRegister(c)
+}
+
+// Handler é o endpoint GET /metrics, no formato do Prometheus.
+// Com token, exige o header "Authorization: Bearer <token>".
+func (m *Metrics) Handler(token string) gin.HandlerFunc {
+	h := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		// Uma falha num coletor (ex.: banco fora do ar) não derruba as outras métricas
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+	return func(c *gin.Context) {
+		if token != "" {
+			expected := "Bearer " + token
+			if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(expected)) != 1 {
+				apierror.Abort(c, http.StatusUnauthorized, "Token de métricas inválido")
+				return
+			}
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
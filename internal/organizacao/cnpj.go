@@ -0,0 +1,53 @@
+package organizacao
+
+import "strings"
+
+// NormalizeCNPJ remove a pontuação do CNPJ (ex.: "11.222.333/0001-81")
+// e valida os dígitos verificadores. Retorna os 14 dígitos e se é válido.
+func NormalizeCNPJ(cnpj string) (string, bool) {
+	// 1. Mantém apenas os dígitos
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		if r == '.' || r == '/' || r == '-' || r == ' ' {
+			return -1
+		}
+		return 'x' // Qualquer outro caractere invalida o CNPJ
+	}, cnpj)
+
+	if len(digits) != 14 || strings.Contains(digits, "x") {
+		return "", false
+	}
+
+	// 2. Rejeita sequências repetidas (ex.: 00000000000000), que passam no cálculo
+	if strings.Count(digits, digits[:1]) == 14 {
+		return "", false
+	}
+
+	// 3. Confere os dois dígitos verificadores
+	if checkDigit(digits[:12]) != digits[12] || checkDigit(digits[:13]) != digits[13] {
+		return "", false
+	}
+
+	return digits, true
+}
+
+// checkDigit calcula o dígito verificador (módulo 11) dos dígitos informados
+func checkDigit(digits string) byte {
+	// Pesos vão de 2 a 9, da direita para a esquerda, reiniciando após o 9
+	sum, weight := 0, 2
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight++
+		if weight > 9 {
+			weight = 2
+		}
+	}
+
+	rest := sum % 11
+	if rest < 2 {
+		return '0'
+	}
+	return byte('0' + 11 - rest)
+}
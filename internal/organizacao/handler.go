@@ -0,0 +1,190 @@
+package organizacao
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/database"
+	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// Handler gerencia as requisições HTTP relacionadas às organizações
+type Handler struct {
+	repo         *Repository
+	ecopontoRepo *ecoponto.Repository
+}
+
+// NewHandler cria uma nova instância do handler
+func NewHandler(repo *Repository, ecopontoRepo *ecoponto.Repository) *Handler {
+	return &Handler{repo: repo, ecopontoRepo: ecopontoRepo}
+}
+
+// CreateOrganizacao é o método para o endpoint POST /api/organizacoes
+func (h *Handler) CreateOrganizacao(c *gin.Context) {
+	// 1. Faz o "bind" do JSON
+	var req CreateOrganizacaoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Valida e normaliza o CNPJ
+	cnpj, ok := NormalizeCNPJ(req.CNPJ)
+	if !ok {
+		apierror.Respond(c, http.StatusBadRequest, "CNPJ inválido")
+		return
+	}
+	req.CNPJ = cnpj
+
+	// 3. Chama o repositório
+	org, err := h.repo.Create(c.Request.Context(), tenant.IDFromContext(c), req)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			apierror.Respond(c, http.StatusConflict, "Já existe uma organização com este CNPJ")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Retorna o objeto criado
+	c.JSON(http.StatusCreated, org)
+}
+
+// ListOrganizacoes é o método para o endpoint GET /api/organizacoes
+func (h *Handler) ListOrganizacoes(c *gin.Context) {
+	orgs, err := h.repo.List(c.Request.Context(), tenant.IDFromContext(c), c.Query("tipo"))
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+// GetOrganizacao é o método para o endpoint GET /api/organizacoes/:id
+func (h *Handler) GetOrganizacao(c *gin.Context) {
+	id, ok := organizacaoID(c)
+	if !ok {
+		return
+	}
+	org, err := h.repo.GetByID(c.Request.Context(), tenant.IDFromContext(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Organização não encontrada")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// ListEcopontosDaOrganizacao é o método para o endpoint GET /api/organizacoes/:id/ecopontos
+func (h *Handler) ListEcopontosDaOrganizacao(c *gin.Context) {
+	id, ok := organizacaoID(c)
+	if !ok {
+		return
+	}
+	tenantID := tenant.IDFromContext(c)
+
+	// 1. Garante que a organização existe (para diferenciar 404 de lista vazia)
+	if _, err := h.repo.GetByID(c.Request.Context(), tenantID, id); err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Organização não encontrada")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 2. Lista os ecopontos operados por ela
+	pontos, err := h.ecopontoRepo.ListByOrganizacao(c.Request.Context(), tenantID, id)
+	if err != nil {
+		apierror.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pontos)
+}
+
+// UpdateOrganizacao é o método para o endpoint PUT /api/organizacoes/:id
+func (h *Handler) UpdateOrganizacao(c *gin.Context) {
+	id, ok := organizacaoID(c)
+	if !ok {
+		return
+	}
+
+	// 1. Faz o "bind" do JSON
+	var req UpdateOrganizacaoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 2. Se o CNPJ foi enviado, valida e normaliza
+	if req.CNPJ != nil {
+		cnpj, ok := NormalizeCNPJ(*req.CNPJ)
+		if !ok {
+			apierror.Respond(c, http.StatusBadRequest, "CNPJ inválido")
+			return
+		}
+		req.CNPJ = &cnpj
+	}
+
+	// 3. Chama o repositório
+	org, err := h.repo.Update(c.Request.Context(), tenant.IDFromContext(c), id, req)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Organização não encontrada")
+			return
+		}
+		if database.IsUniqueViolation(err) {
+			apierror.Respond(c, http.StatusConflict, "Já existe uma organização com este CNPJ")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	// 4. Retorna o objeto atualizado
+	c.JSON(http.StatusOK, org)
+}
+
+// DeleteOrganizacao é o método para o endpoint DELETE /api/organizacoes/:id
+func (h *Handler) DeleteOrganizacao(c *gin.Context) {
+	id, ok := organizacaoID(c)
+	if !ok {
+		return
+	}
+	err := h.repo.Delete(c.Request.Context(), tenant.IDFromContext(c), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Respond(c, http.StatusNotFound, "Organização não encontrada")
+			return
+		}
+		// Ainda existem ecopontos vinculados a esta organização
+		if database.IsForeignKeyViolation(err) {
+			apierror.Respond(c, http.StatusConflict, "Organização possui ecopontos vinculados")
+			return
+		}
+		apierror.Internal(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// organizacaoID lê o :id da URL. Um id que não é UUID não existe no banco,
+// então responde 404 (em vez de deixar o Postgres recusar o valor com um 500).
+func organizacaoID(c *gin.Context) (string, bool) {
+	var uri struct {
+		ID string `uri:"id" binding:"uuid"`
+	}
+	if err := c.ShouldBindUri(&uri); err != nil {
+		apierror.Respond(c, http.StatusNotFound, "Organização não encontrada")
+		return "", false
+	}
+	return uri.ID, true
+}
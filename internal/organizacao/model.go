@@ -0,0 +1,38 @@
+package organizacao
+
+import "time"
+
+// Tipos de organização que podem operar um ecoponto
+const (
+	TipoPrefeitura  = "prefeitura"
+	TipoCooperativa = "cooperativa"
+	TipoEmpresa     = "empresa"
+)
+
+// Organizacao representa quem opera os ecopontos (prefeitura, cooperativa ou empresa)
+type Organizacao struct {
+	ID             string    `db:"id" json:"id"`
+	Nome           string    `db:"nome" json:"nome"`
+	CNPJ           string    `db:"cnpj" json:"cnpj"`
+	Tipo           string    `db:"tipo" json:"tipo"`
+	Email          *string   `db:"email" json:"email,omitempty"`
+	Telefone       *string   `db:"telefone" json:"telefone,omitempty"`
+	TotalEcopontos int       `db:"total_ecopontos" json:"total_ecopontos"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type CreateOrganizacaoRequest struct {
+	Nome     string  `json:"nome" binding:"required,max=255"`
+	CNPJ     string  `json:"cnpj" binding:"required"`
+	Tipo     string  `json:"tipo" binding:"required,oneof=prefeitura cooperativa empresa"`
+	Email    *string `json:"email" binding:"omitempty,email,max=255"`
+	Telefone *string `json:"telefone" binding:"omitempty,e164"`
+}
+
+type UpdateOrganizacaoRequest struct {
+	Nome     *string `json:"nome" binding:"omitempty,max=255"`
+	CNPJ     *string `json:"cnpj"`
+	Tipo     *string `json:"tipo" binding:"omitempty,oneof=prefeitura cooperativa empresa"`
+	Email    *string `json:"email" binding:"omitempty,email,max=255"`
+	Telefone *string `json:"telefone" binding:"omitempty,e164"`
+}
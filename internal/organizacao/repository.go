@@ -0,0 +1,134 @@
+package organizacao
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// organizacaoSelect lê as organizações junto com a contagem de ecopontos operados
+const organizacaoSelect = `
+	SELECT
+		o.id, o.nome, o.cnpj, o.tipo, o.email, o.telefone, o.created_at,
+		(SELECT COUNT(*) FROM ecopontos e WHERE e.organizacao_id = o.id) AS total_ecopontos
+	FROM
+		organizacoes o`
+
+// Repository gerencia a persistência das organizações
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository cria uma nova instância do repositório
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create insere uma nova organização no município (o CNPJ já deve estar normalizado)
+func (r *Repository) Create(ctx context.Context, tenantID string, req CreateOrganizacaoRequest) (*Organizacao, error) {
+	query := `
+		INSERT INTO organizacoes (nome, cnpj, tipo, email, telefone, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, nome, cnpj, tipo, email, telefone, created_at
+	`
+	var org Organizacao
+	err := r.db.QueryRowxContext(ctx, query, req.Nome, req.CNPJ, req.Tipo, req.Email, req.Telefone, tenantID).StructScan(&org)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// List lista as organizações do município, opcionalmente filtrando pelo tipo
+func (r *Repository) List(ctx context.Context, tenantID, tipo string) ([]Organizacao, error) {
+	query := organizacaoSelect + `
+		WHERE
+			o.tenant_id = $1
+			AND ($2 = '' OR o.tipo = $2)
+		ORDER BY
+			o.nome
+	`
+	var orgs []Organizacao
+	err := r.db.SelectContext(ctx, &orgs, query, tenantID, tipo)
+	if err != nil {
+		return nil, err
+	}
+	if orgs == nil {
+		orgs = make([]Organizacao, 0)
+	}
+	return orgs, nil
+}
+
+// GetByID busca uma organização pelo seu ID
+func (r *Repository) GetByID(ctx context.Context, tenantID, id string) (*Organizacao, error) {
+	query := organizacaoSelect + `
+		WHERE
+			o.id = $1 AND o.tenant_id = $2
+	`
+	var org Organizacao
+	err := r.db.GetContext(ctx, &org, query, id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Update atualiza apenas os campos enviados (não nulos) no request
+func (r *Repository) Update(ctx context.Context, tenantID, id string, req UpdateOrganizacaoRequest) (*Organizacao, error) {
+	// 1. Inicia o construtor de SQL
+	qb := sq.Update("organizacoes").
+		PlaceholderFormat(sq.Dollar).
+		Where(sq.Eq{"id": id, "tenant_id": tenantID}).
+		Suffix(`RETURNING id, nome, cnpj, tipo, email, telefone, created_at,
+			(SELECT COUNT(*) FROM ecopontos e WHERE e.organizacao_id = organizacoes.id) AS total_ecopontos`)
+
+	// 2. Adiciona os campos enviados
+	if req.Nome != nil {
+		qb = qb.Set("nome", *req.Nome)
+	}
+	if req.CNPJ != nil {
+		qb = qb.Set("cnpj", *req.CNPJ)
+	}
+	if req.Tipo != nil {
+		qb = qb.Set("tipo", *req.Tipo)
+	}
+	if req.Email != nil {
+		qb = qb.Set("email", *req.Email)
+	}
+	if req.Telefone != nil {
+		qb = qb.Set("telefone", *req.Telefone)
+	}
+
+	// 3. Constrói e executa a query
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var org Organizacao
+	err = r.db.QueryRowxContext(ctx, query, args...).StructScan(&org)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Delete remove uma organização pelo seu ID.
+// Falha com violação de chave estrangeira se ainda houver ecopontos vinculados.
+func (r *Repository) Delete(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM organizacoes WHERE id = $1 AND tenant_id = $2", id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/ericoliveiras/ecoponto-api/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader é o header que carrega o ID da requisição (na entrada e na resposta)
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen limita o ID aceito do cliente ou do proxy
+const maxRequestIDLen = 128
+
+// RequestIDMiddleware aproveita o X-Request-ID enviado pelo proxy/cliente (se for válido)
+// ou gera um novo. O ID volta no header da resposta e vai para o contexto da
+// requisição, de onde os logs e as respostas de erro o leem.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// AccessLogMiddleware registra uma linha por requisição atendida
+func AccessLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		slog.Log(c.Request.Context(), level, "Requisição",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"ip", c.ClientIP(),
+			"user_agent", c.Request.UserAgent(),
+			"bytes", c.Writer.Size(),
+		)
+	}
+}
+
+// RecoveryMiddleware transforma um panic num 500 no formato padrão,
+// com o stack trace só no log
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					// O cliente desconectou: não há a quem responder
+					panic(r)
+				}
+				slog.ErrorContext(c.Request.Context(), "Panic ao atender a requisição",
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()),
+				)
+				if !c.Writer.Written() {
+					apierror.Abort(c, http.StatusInternalServerError, apierror.MensagemInterna)
+				} else {
+					c.Abort()
+				}
+			}
+		}()
+		c.Next()
+	}
+}
+
+// validRequestID aceita só IDs curtos e com caracteres seguros para o log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID gera um ID aleatório de 16 bytes em hexadecimal
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
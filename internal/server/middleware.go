@@ -1,30 +1,183 @@
 package server
 
 import (
+	"database/sql"
+	"errors"
+	"net"
 	"net/http"
+	"strings"
 
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
 	"github.com/ericoliveiras/ecoponto-api/internal/auth"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
+	"github.com/ericoliveiras/ecoponto-api/internal/user"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 // AuthMiddleware cria um middleware Gin para validar o JWT
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// e recusar tokens de sessões encerradas (logout ou refresh token reutilizado).
+// Clientes máquina-a-máquina podem se autenticar com uma chave de API no lugar do JWT.
+func AuthMiddleware(keys *auth.KeySet, sessions *auth.SessionRepository, apiKeys *auth.APIKeyRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Chave de API: as permissões vêm dos scopes da chave
+		if key := auth.APIKeyFromRequest(c); key != "" {
+			k, err := apiKeys.Authenticate(c.Request.Context(), tenant.IDFromContext(c), key)
+			if err != nil {
+				if errors.Is(err, auth.ErrAPIKeyInvalida) {
+					apierror.Abort(c, http.StatusUnauthorized, err.Error())
+					return
+				}
+				apierror.AbortInternal(c, err)
+				return
+			}
+			auth.SetPrincipal(c, principalFromAPIKey(k))
+			c.Next()
+			return
+		}
+
 		// Valida o token
-		token, err := auth.ValidateToken(c, jwtSecret)
+		token, err := auth.ValidateToken(c, keys)
 		if err != nil {
 			// Se o token for inválido, bloqueia a requisição
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			apierror.Abort(c, http.StatusUnauthorized, err.Error())
 			return
 		}
 
-		// Armazena os claims no contexto para uso posterior
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("claims", claims)
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
+			apierror.Abort(c, http.StatusUnauthorized, "token inválido")
+			return
+		}
+
+		// O token só vale para o município em que o usuário foi cadastrado
+		if tenantID, _ := claims["tenant"].(string); tenantID == "" || tenantID != tenant.IDFromContext(c) {
+			apierror.Abort(c, http.StatusForbidden, "Token não pertence a este município")
+			return
 		}
 
+		// O access token vale enquanto a sessão que o emitiu estiver aberta
+		sid, _ := claims["sid"].(string)
+		if sid == "" {
+			apierror.Abort(c, http.StatusUnauthorized, "token inválido")
+			return
+		}
+		active, err := sessions.IsActive(c.Request.Context(), sid)
+		if err != nil {
+			apierror.AbortInternal(c, err)
+			return
+		}
+		if !active {
+			apierror.Abort(c, http.StatusUnauthorized, "Sessão encerrada")
+			return
+		}
+
+		// Armazena os claims e o usuário autenticado no contexto para uso posterior
+		c.Set("claims", claims)
+		auth.SetPrincipal(c, principalFromClaims(claims))
+
 		// Token é válido, continua para o próximo handler
 		c.Next()
 	}
 }
+
+// principalFromClaims monta o usuário autenticado a partir dos claims do JWT
+func principalFromClaims(claims jwt.MapClaims) *auth.Principal {
+	p := &auth.Principal{}
+	p.UserID, _ = claims["sub"].(string)
+	p.TenantID, _ = claims["tenant"].(string)
+	p.Email, _ = claims["email"].(string)
+	p.Role, _ = claims["role"].(string)
+	p.SessionID, _ = claims["sid"].(string)
+	return p
+}
+
+// principalFromAPIKey monta o cliente autenticado a partir da chave de API
+func principalFromAPIKey(k *auth.APIKey) *auth.Principal {
+	p := &auth.Principal{TenantID: k.TenantID, APIKeyID: k.ID}
+	for _, s := range k.Scopes {
+		p.Scopes = append(p.Scopes, auth.Permission(s))
+	}
+	return p
+}
+
+// RequireUser cria um middleware Gin que recusa chaves de API
+// (rotas que só fazem sentido para uma pessoa, como trocar a própria senha)
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p := auth.PrincipalFromContext(c); p == nil || p.IsAPIKey() {
+			apierror.Abort(c, http.StatusForbidden, "Rota disponível apenas para usuários")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission cria um middleware Gin que só deixa passar usuários
+// cujo papel tem a permissão. Deve vir depois do AuthMiddleware.
+func RequirePermission(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p := auth.PrincipalFromContext(c)
+		if p == nil || !p.Can(perm) {
+			apierror.Abort(c, http.StatusForbidden, "Permissão insuficiente")
+			return
+		}
+		c.Next()
+	}
+}
+
+// PlatformOperator retorna a regra que identifica os operadores da plataforma:
+// superadmins (pessoas, não chaves de API) do município padrão da instalação.
+// Só eles mexem no que é compartilhado por todos os municípios.
+func PlatformOperator(defaultSlug string) func(c *gin.Context) bool {
+	return func(c *gin.Context) bool {
+		p := auth.PrincipalFromContext(c)
+		t := tenant.FromContext(c)
+		return p != nil && !p.IsAPIKey() && p.Role == user.RoleSuperadmin &&
+			t != nil && t.Slug == defaultSlug
+	}
+}
+
+// TenantMiddleware resolve o município da requisição, nesta ordem:
+// prefixo da URL (/t/:tenant/api), header Host e, por fim, o município padrão.
+func TenantMiddleware(repo *tenant.Repository, defaultSlug string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var t *tenant.Tenant
+		var err error
+
+		if slug := c.Param("tenant"); slug != "" {
+			// 1. Prefixo explícito na URL
+			t, err = repo.FindBySlug(ctx, slug)
+		} else {
+			// 2. Domínio configurado para o município
+			t, err = repo.FindByHost(ctx, requestHost(c))
+			if err == sql.ErrNoRows {
+				// 3. Município padrão da instalação
+				t, err = repo.FindBySlug(ctx, defaultSlug)
+			}
+		}
+
+		if err != nil {
+			if err == sql.ErrNoRows {
+				apierror.Abort(c, http.StatusNotFound, "Município não encontrado")
+				return
+			}
+			apierror.AbortInternal(c, err)
+			return
+		}
+
+		tenant.SetInContext(c, t)
+		c.Next()
+	}
+}
+
+// requestHost retorna o header Host sem a porta e em minúsculas
+func requestHost(c *gin.Context) string {
+	host := c.Request.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
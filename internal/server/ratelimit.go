@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// ipLimiter é o balde de tokens de um IP
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimitByIP cria um middleware que aceita até porHora requisições por hora de
+// cada IP (todas de uma vez, no máximo porHora), respondendo 429 com Retry-After
+// acima disso. O controle é em memória, por instância da API.
+func RateLimitByIP(porHora int) gin.HandlerFunc {
+	every := rate.Every(time.Hour / time.Duration(porHora))
+	// Um IP parado por uma hora tem o balde cheio de novo: a entrada pode ser descartada
+	idle := time.Hour
+
+	var (
+		mu        sync.Mutex
+		limiters  = make(map[string]*ipLimiter)
+		lastSweep = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		ip := c.ClientIP()
+
+		mu.Lock()
+		// 1. Descarta de tempos em tempos os IPs parados (o mapa não cresce sem limite)
+		if now.Sub(lastSweep) > time.Minute {
+			for k, l := range limiters {
+				if now.Sub(l.lastSeen) > idle {
+					delete(limiters, k)
+				}
+			}
+			lastSweep = now
+		}
+
+		// 2. Reserva um token do balde do IP
+		l, ok := limiters[ip]
+		if !ok {
+			l = &ipLimiter{limiter: rate.NewLimiter(every, porHora)}
+			limiters[ip] = l
+		}
+		l.lastSeen = now
+		r := l.limiter.ReserveN(now, 1)
+		wait := r.DelayFrom(now)
+		if wait > 0 {
+			// Sem token: devolve a reserva, para a recusa não atrasar ainda mais o IP
+			r.CancelAt(now)
+		}
+		mu.Unlock()
+
+		if wait > 0 {
+			seconds := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			apierror.Abort(c, http.StatusTooManyRequests, fmt.Sprintf("Muitas requisições. Tente novamente em %d segundos", seconds))
+			return
+		}
+		c.Next()
+	}
+}
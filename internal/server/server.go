@@ -1,28 +1,86 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/ericoliveiras/ecoponto-api/internal/auth"
 	"github.com/ericoliveiras/ecoponto-api/internal/ecoponto"
+	"github.com/ericoliveiras/ecoponto-api/internal/geocoding"
+	"github.com/ericoliveiras/ecoponto-api/internal/health"
+	"github.com/ericoliveiras/ecoponto-api/internal/item"
+	"github.com/ericoliveiras/ecoponto-api/internal/job"
+	"github.com/ericoliveiras/ecoponto-api/internal/metrics"
+	"github.com/ericoliveiras/ecoponto-api/internal/organizacao"
+	"github.com/ericoliveiras/ecoponto-api/internal/tenant"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
+// Deps agrupa os handlers e dependências que o servidor usa para montar as rotas
+type Deps struct {
+	EcopontoHandler    *ecoponto.Handler
+	AuthHandler        *auth.Handler
+	UserHandler        *auth.UserHandler
+	ResetHandler       *auth.ResetHandler
+	ItemHandler        *item.Handler
+	OrganizacaoHandler *organizacao.Handler
+	TenantHandler      *tenant.Handler
+	GeocodingHandler   *geocoding.Handler
+	JobHandler         *job.Handler
+	HealthHandler      *health.Handler
+	Metrics            *metrics.Metrics
+	MetricsToken       string // Se definido, GET /metrics exige "Authorization: Bearer <token>"
+	TenantRepo         *tenant.Repository
+	SessionRepo        *auth.SessionRepository
+	APIKeyRepo         *auth.APIKeyRepository
+	APIKeyHandler      *auth.APIKeyHandler
+	MFAHandler         *auth.MFAHandler
+	OIDCHandler        *auth.OIDCHandler // nil quando o login OIDC não está configurado
+	JWTKeys            *auth.KeySet
+	DefaultTenant      string   // Slug do município usado quando a requisição não identifica nenhum
+	TrustedProxies     []string // Proxies cujo X-Forwarded-For define o IP do cliente (vazio = nenhum)
+	ForgotPorIP        int      // Pedidos de redefinição de senha aceitos por hora de um mesmo IP
+	ServiceName        string   // service.name dos spans do OpenTelemetry
+}
+
 // Server é a struct principal do servidor
 type Server struct {
-	router      *gin.Engine
-	ecopontoHdl *ecoponto.Handler
-	authHdl     *auth.Handler
-	jwtSecret   string
+	router *gin.Engine
+	deps   Deps
 }
 
 // NewServer cria e configura o servidor com todas as rotas
-func NewServer(ecopontoHdl *ecoponto.Handler, authHdl *auth.Handler, jwtSecret string) *Server {
-	// Cria o router Gin
-	r := gin.Default()
+func NewServer(deps Deps) (*Server, error) {
+	// Cria o router Gin (sem os middlewares padrão: log e recovery são os nossos, em JSON)
+	r := gin.New()
+
+	// O IP do cliente (proteção do login, auditoria, logs) só vem do X-Forwarded-For
+	// quando a conexão chega de um proxy confiável; sem proxies, é o IP da conexão
+	if err := r.SetTrustedProxies(deps.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+
+	// Span de cada requisição, continuando o trace do cliente (header traceparent).
+	// Health checks e /metrics ficam de fora, para o scrape e as probes não encherem o coletor.
+	r.Use(otelgin.Middleware(deps.ServiceName, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		switch c.FullPath() {
+		case "/healthz", "/readyz", "/ping", "/metrics":
+			return false
+		}
+		return true
+	})))
+
+	// ID da requisição, log de acesso, métricas (GET /metrics) e recovery de panics
+	r.Use(RequestIDMiddleware())
+	r.Use(AccessLogMiddleware())
+	r.Use(deps.Metrics.Middleware())
+	r.Use(RecoveryMiddleware())
 
 	r.Use(cors.New(cors.Config{
 		// Permite que origens específicas façam requisições
@@ -33,10 +91,10 @@ func NewServer(ecopontoHdl *ecoponto.Handler, authHdl *auth.Handler, jwtSecret s
 		AllowMethods: []string{"GET", "POST", "PUT", "DELETE"},
 
 		// Quais headers o frontend pode enviar
-		AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders: []string{"Origin", "Content-Type", "Authorization", RequestIDHeader},
 
-		// Permite que o navegador exponha o header "Authorization"
-		ExposeHeaders: []string{"Content-Length"},
+		// Headers da resposta que o frontend pode ler
+		ExposeHeaders: []string{"Content-Length", RequestIDHeader},
 
 		// Permite que cookies/credenciais sejam enviados
 		AllowCredentials: true,
@@ -47,56 +105,178 @@ func NewServer(ecopontoHdl *ecoponto.Handler, authHdl *auth.Handler, jwtSecret s
 
 	// Cria a struct do servidor
 	s := &Server{
-		router:      r,
-		ecopontoHdl: ecopontoHdl,
-		authHdl:     authHdl,
-		jwtSecret:   jwtSecret,
+		router: r,
+		deps:   deps,
 	}
 
 	// Registra todas as nossas rotas
 	s.registerRoutes()
 
-	return s
+	return s, nil
 }
 
 // registerRoutes é um método privado para organizar o registro
 func (s *Server) registerRoutes() {
 	// --- Rotas Públicas  ---
-	// Health Check
-	s.router.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message":   "pong",
-			"db_status": "conectado",
-		})
+	// Health checks: processo de pé (liveness) e dependências prontas (readiness)
+	s.router.GET("/healthz", s.deps.HealthHandler.Healthz)
+	s.router.GET("/readyz", s.deps.HealthHandler.Readyz)
+	s.router.GET("/ping", s.deps.HealthHandler.Ping)
+
+	// Métricas no formato do Prometheus
+	s.router.GET("/metrics", s.deps.Metrics.Handler(s.deps.MetricsToken))
+
+	// Chaves públicas para outros serviços validarem os nossos tokens
+	s.router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, s.deps.JWTKeys.JWKS())
 	})
 
-	apiPublic := s.router.Group("/api")
+	// O município é resolvido pelo Host (ex.: ecopontos.recife.pe.gov.br/api)
+	// ou explicitamente pelo prefixo da URL (ex.: /t/recife/api)
+	s.registerAPIRoutes(s.router.Group("/api"))
+	s.registerAPIRoutes(s.router.Group("/t/:tenant/api"))
+}
+
+// registerAPIRoutes registra as rotas da API no grupo informado
+func (s *Server) registerAPIRoutes(api *gin.RouterGroup) {
+	// Todas as rotas da API dependem do município
+	api.Use(TenantMiddleware(s.deps.TenantRepo, s.deps.DefaultTenant))
+
+	apiPublic := api.Group("")
 	{
-		apiPublic.GET("/ecopontos", s.ecopontoHdl.ListEcopontos)
-		apiPublic.GET("/ecopontos/:id", s.ecopontoHdl.GetEcoponto)
-		apiPublic.POST("/auth/login", s.authHdl.Login)
+		apiPublic.GET("/municipio", s.deps.TenantHandler.GetCurrent)
+		apiPublic.GET("/ecopontos", s.deps.EcopontoHandler.ListEcopontos)
+		apiPublic.GET("/ecopontos/:id", s.deps.EcopontoHandler.GetEcoponto)
+		apiPublic.GET("/itens", s.deps.ItemHandler.SearchItens)
+		apiPublic.GET("/itens/:id/descarte", s.deps.ItemHandler.GetDescarte)
+		apiPublic.GET("/organizacoes", s.deps.OrganizacaoHandler.ListOrganizacoes)
+		apiPublic.GET("/organizacoes/:id", s.deps.OrganizacaoHandler.GetOrganizacao)
+		apiPublic.GET("/organizacoes/:id/ecopontos", s.deps.OrganizacaoHandler.ListEcopontosDaOrganizacao)
+		apiPublic.POST("/auth/login", s.deps.AuthHandler.Login)
+		apiPublic.POST("/auth/login/2fa", s.deps.AuthHandler.LoginMFA)
+		apiPublic.POST("/auth/login/2fa/cadastro", s.deps.AuthHandler.LoginMFAEnroll)
+		apiPublic.POST("/auth/refresh", s.deps.AuthHandler.Refresh)
+		apiPublic.POST("/auth/logout", s.deps.AuthHandler.Logout)
+		apiPublic.POST("/auth/forgot", RateLimitByIP(s.deps.ForgotPorIP), s.deps.ResetHandler.ForgotPassword)
+		apiPublic.POST("/auth/reset", s.deps.ResetHandler.ResetPassword)
+
+		if s.deps.OIDCHandler != nil {
+			apiPublic.GET("/auth/oidc/login", s.deps.OIDCHandler.Login)
+			apiPublic.GET("/auth/oidc/callback", s.deps.OIDCHandler.Callback)
+		}
 	}
 
 	// --- Rotas de Admin (protegidas com JWT) ---
-	apiAdmin := s.router.Group("/api")
+	apiAdmin := api.Group("")
 
 	// Aplica o middleware de autenticação a este grupo
-	apiAdmin.Use(AuthMiddleware(s.jwtSecret))
+	apiAdmin.Use(AuthMiddleware(s.deps.JWTKeys, s.deps.SessionRepo, s.deps.APIKeyRepo))
 	{
-		apiAdmin.POST("/ecopontos", s.ecopontoHdl.CreateEcoponto)
-		apiAdmin.PUT("/ecopontos/:id", s.ecopontoHdl.UpdateEcoponto)
-		apiAdmin.DELETE("/ecopontos/:id", s.ecopontoHdl.DeleteEcoponto)
-		apiAdmin.GET("/ecopontos/all", s.ecopontoHdl.ListAllEcopontos)
+		apiAdmin.POST("/ecopontos", RequirePermission(auth.PermEcopontosCriar), s.deps.EcopontoHandler.CreateEcoponto)
+		apiAdmin.PUT("/ecopontos/:id", RequirePermission(auth.PermEcopontosEditar), s.deps.EcopontoHandler.UpdateEcoponto)
+		apiAdmin.DELETE("/ecopontos/:id", RequirePermission(auth.PermEcopontosExcluir), s.deps.EcopontoHandler.DeleteEcoponto)
+		apiAdmin.GET("/ecopontos/all", RequirePermission(auth.PermEcopontosLer), s.deps.EcopontoHandler.ListAllEcopontos)
+		apiAdmin.POST("/ecopontos/importacao", RequirePermission(auth.PermEcopontosCriar), s.deps.JobHandler.CreateImportacao)
+		apiAdmin.GET("/jobs/:id", RequirePermission(auth.PermJobsLer), s.deps.JobHandler.GetJob)
+
+		apiAdmin.POST("/organizacoes", RequirePermission(auth.PermOrganizacoesEditar), s.deps.OrganizacaoHandler.CreateOrganizacao)
+		apiAdmin.PUT("/organizacoes/:id", RequirePermission(auth.PermOrganizacoesEditar), s.deps.OrganizacaoHandler.UpdateOrganizacao)
+		apiAdmin.DELETE("/organizacoes/:id", RequirePermission(auth.PermOrganizacoesExcluir), s.deps.OrganizacaoHandler.DeleteOrganizacao)
+
+		apiAdmin.GET("/usuarios", RequirePermission(auth.PermUsuariosGerenciar), s.deps.UserHandler.ListUsers)
+		apiAdmin.POST("/usuarios", RequirePermission(auth.PermUsuariosGerenciar), s.deps.UserHandler.InviteUser)
+		apiAdmin.GET("/usuarios/:id", RequirePermission(auth.PermUsuariosGerenciar), s.deps.UserHandler.GetUser)
+		apiAdmin.PUT("/usuarios/:id", RequirePermission(auth.PermUsuariosGerenciar), s.deps.UserHandler.UpdateUser)
+		apiAdmin.DELETE("/usuarios/:id", RequirePermission(auth.PermUsuariosGerenciar), s.deps.UserHandler.DeleteUser)
+		apiAdmin.POST("/usuarios/:id/desbloquear", RequirePermission(auth.PermUsuariosGerenciar), s.deps.UserHandler.UnlockUser)
+		apiAdmin.GET("/usuarios/:id/tentativas", RequirePermission(auth.PermUsuariosGerenciar), s.deps.UserHandler.ListLoginAttempts)
+		apiAdmin.DELETE("/usuarios/:id/2fa", RequirePermission(auth.PermUsuariosGerenciar), s.deps.MFAHandler.ResetUserMFA)
+		apiAdmin.POST("/usuarios/:id/2fa/cadastro", RequirePermission(auth.PermUsuariosGerenciar), s.deps.MFAHandler.IssueEnrollmentCode)
+
+		// Qualquer usuário autenticado pode trocar a própria senha e cuidar do próprio 2FA
+		apiAdmin.PUT("/auth/senha", RequireUser(), s.deps.UserHandler.ChangePassword)
+		apiAdmin.GET("/auth/2fa", RequireUser(), s.deps.MFAHandler.GetStatus)
+		apiAdmin.POST("/auth/2fa", RequireUser(), s.deps.MFAHandler.StartEnrollment)
+		apiAdmin.POST("/auth/2fa/confirmar", RequireUser(), s.deps.MFAHandler.ConfirmEnrollment)
+		apiAdmin.POST("/auth/2fa/codigos-recuperacao", RequireUser(), s.deps.MFAHandler.RegenerateRecoveryCodes)
+		apiAdmin.POST("/auth/2fa/desativar", RequireUser(), s.deps.MFAHandler.Disable)
+
+		apiAdmin.GET("/apikeys", RequirePermission(auth.PermAPIKeysGerenciar), s.deps.APIKeyHandler.ListAPIKeys)
+		apiAdmin.POST("/apikeys", RequirePermission(auth.PermAPIKeysGerenciar), s.deps.APIKeyHandler.CreateAPIKey)
+		apiAdmin.DELETE("/apikeys/:id", RequirePermission(auth.PermAPIKeysGerenciar), s.deps.APIKeyHandler.RevokeAPIKey)
+
+		apiAdmin.GET("/geocode/search", RequirePermission(auth.PermGeocodingUsar), s.deps.GeocodingHandler.Search)
+		apiAdmin.GET("/geocode/reverse", RequirePermission(auth.PermGeocodingUsar), s.deps.GeocodingHandler.Reverse)
+		apiAdmin.GET("/geocode/cache", RequirePermission(auth.PermGeocodingCache), s.deps.GeocodingHandler.ListCache)
+		apiAdmin.DELETE("/geocode/cache", RequirePermission(auth.PermGeocodingCache), s.deps.GeocodingHandler.PurgeCache)
 	}
 }
 
-// Run inicia o servidor HTTP
-func (s *Server) Run(port string) error {
-	addr := fmt.Sprintf(":%s", port)
-	if port == "" {
+// HTTPOptions configura o servidor HTTP
+type HTTPOptions struct {
+	Port              string
+	ReadTimeout       time.Duration // Tempo máximo para ler a requisição inteira (com o corpo)
+	ReadHeaderTimeout time.Duration // Tempo máximo para ler os headers
+	WriteTimeout      time.Duration // Tempo máximo para escrever a resposta
+	IdleTimeout       time.Duration // Tempo que uma conexão keep-alive fica aberta sem requisições
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration // Prazo para as requisições em andamento terminarem no desligamento
+	TLSCertFile       string        // Certificado e chave em PEM; vazios = HTTP sem TLS
+	TLSKeyFile        string
+}
+
+// Run inicia o servidor HTTP e bloqueia até o contexto ser cancelado (SIGTERM/SIGINT).
+// Então para de aceitar conexões e espera as requisições em andamento terminarem,
+// até o ShutdownTimeout; as que passarem do prazo são interrompidas.
+func (s *Server) Run(ctx context.Context, opts HTTPOptions) error {
+	addr := fmt.Sprintf(":%s", opts.Port)
+	if opts.Port == "" {
 		addr = ":8080" // Fallback
 	}
 
-	fmt.Printf("Servidor subindo na porta %s\n", addr)
-	return s.router.Run(addr)
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.router,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+	}
+
+	// 1. Sobe o servidor em segundo plano
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if opts.TLSCertFile != "" {
+			slog.Info("Servidor subindo", "addr", addr, "tls", true)
+			err = httpServer.ListenAndServeTLS(opts.TLSCertFile, opts.TLSKeyFile)
+		} else {
+			slog.Info("Servidor subindo", "addr", addr, "tls", false)
+			err = httpServer.ListenAndServe()
+		}
+		errCh <- err
+	}()
+
+	// 2. Espera o sinal de desligamento (ou uma falha ao subir, ex.: porta em uso)
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// 3. Drena as requisições em andamento dentro do prazo
+	slog.Info("Desligando o servidor", "prazo", opts.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		httpServer.Close()
+		return fmt.Errorf("requisições interrompidas no desligamento: %w", err)
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
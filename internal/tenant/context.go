@@ -0,0 +1,29 @@
+package tenant
+
+import "github.com/gin-gonic/gin"
+
+// contextKey é a chave onde o middleware guarda o município resolvido
+const contextKey = "tenant"
+
+// SetInContext guarda o município resolvido no contexto da requisição
+func SetInContext(c *gin.Context, t *Tenant) {
+	c.Set(contextKey, t)
+}
+
+// FromContext retorna o município da requisição (nil se não foi resolvido)
+func FromContext(c *gin.Context) *Tenant {
+	v, ok := c.Get(contextKey)
+	if !ok {
+		return nil
+	}
+	t, _ := v.(*Tenant)
+	return t
+}
+
+// IDFromContext retorna o ID do município da requisição ("" se não foi resolvido)
+func IDFromContext(c *gin.Context) string {
+	if t := FromContext(c); t != nil {
+		return t.ID
+	}
+	return ""
+}
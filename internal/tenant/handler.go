@@ -0,0 +1,27 @@
+package tenant
+
+import (
+	"github.com/ericoliveiras/ecoponto-api/internal/apierror"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler gerencia as requisições HTTP relacionadas ao município
+type Handler struct{}
+
+// NewHandler cria uma nova instância do handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetCurrent é o método para o endpoint GET /api/municipio
+// Retorna a configuração pública do município resolvido para a requisição
+func (h *Handler) GetCurrent(c *gin.Context) {
+	t := FromContext(c)
+	if t == nil {
+		apierror.Respond(c, http.StatusNotFound, "Município não encontrado")
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
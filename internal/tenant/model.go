@@ -0,0 +1,19 @@
+package tenant
+
+import "time"
+
+// DefaultSlug é o município usado quando nenhum outro é identificado na requisição
+const DefaultSlug = "default"
+
+// Tenant representa um município atendido pela instalação.
+// Ecopontos, usuários e organizações pertencem a um único município.
+type Tenant struct {
+	ID         string    `db:"id" json:"id"`
+	Slug       string    `db:"slug" json:"slug"`
+	Nome       string    `db:"nome" json:"nome"`
+	Host       *string   `db:"host" json:"host,omitempty"`
+	Cidade     *string   `db:"cidade" json:"cidade,omitempty"`
+	Estado     *string   `db:"estado" json:"estado,omitempty"`
+	RaioPadrao int       `db:"raio_padrao" json:"raio_padrao"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
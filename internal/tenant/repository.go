@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Repository gerencia a persistência dos municípios
+type Repository struct {
+	db *sqlx.DB
+}
+
+// NewRepository cria uma nova instância do repositório
+func NewRepository(db *sqlx.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// FindBySlug busca um município pelo seu identificador na URL (ex.: /t/recife/api)
+func (r *Repository) FindBySlug(ctx context.Context, slug string) (*Tenant, error) {
+	var t Tenant
+	err := r.db.GetContext(ctx, &t, "SELECT * FROM tenants WHERE slug = $1", slug)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// FindByHost busca um município pelo domínio configurado (ex.: ecopontos.recife.pe.gov.br)
+func (r *Repository) FindByHost(ctx context.Context, host string) (*Tenant, error) {
+	var t Tenant
+	err := r.db.GetContext(ctx, &t, "SELECT * FROM tenants WHERE host = $1", host)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
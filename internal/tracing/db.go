@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DB embrulha o *sqlx.DB e cria um span para cada consulta feita
+// pelos métodos usados nos repositórios
+type DB struct {
+	*sqlx.DB
+}
+
+// WrapDB cria o DB instrumentado
+func WrapDB(db *sqlx.DB) *DB {
+	return &DB{DB: db}
+}
+
+// GetContext executa a consulta e lê uma linha em dest
+func (d *DB) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := startQuery(ctx, query)
+	err := d.DB.GetContext(ctx, dest, query, args...)
+	endQuery(span, err)
+	return err
+}
+
+// SelectContext executa a consulta e lê todas as linhas em dest
+func (d *DB) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	ctx, span := startQuery(ctx, query)
+	err := d.DB.SelectContext(ctx, dest, query, args...)
+	endQuery(span, err)
+	return err
+}
+
+// ExecContext executa um comando sem ler linhas
+func (d *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	res, err := d.DB.ExecContext(ctx, query, args...)
+	endQuery(span, err)
+	return res, err
+}
+
+// QueryRowxContext executa a consulta; o span termina antes do Scan,
+// que só lê a linha já recebida
+func (d *DB) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	ctx, span := startQuery(ctx, query)
+	row := d.DB.QueryRowxContext(ctx, query, args...)
+	endQuery(span, row.Err())
+	return row
+}
+
+// startQuery abre o span da consulta, nomeado como "<operação> <tabela>" (ex.: "SELECT ecopontos").
+// O SQL vai sem os valores, que ficam nos parâmetros ($1, $2...).
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	op, table := parseQuery(query)
+	name := op
+	if table != "" {
+		name += " " + table
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(op),
+		semconv.DBQueryText(strings.Join(strings.Fields(query), " ")),
+	}
+	if table != "" {
+		attrs = append(attrs, semconv.DBCollectionName(table))
+	}
+	// Método do repositório que fez a consulta (ex.: ecoponto.(*Repository).GetByID)
+	if pc, _, _, ok := runtime.Caller(2); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			attrs = append(attrs, semconv.CodeFunctionName(fn.Name()[strings.LastIndex(fn.Name(), "/")+1:]))
+		}
+	}
+
+	return Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endQuery fecha o span; "nenhuma linha" é um resultado normal, não um erro
+func endQuery(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// parseQuery extrai a operação e a tabela principal do SQL
+func parseQuery(query string) (op, table string) {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL", ""
+	}
+	op = strings.ToUpper(fields[0])
+
+	var after string
+	switch op {
+	case "SELECT", "DELETE":
+		after = "FROM"
+	case "INSERT":
+		after = "INTO"
+	case "UPDATE":
+		if len(fields) > 1 {
+			return op, fields[1]
+		}
+		return op, ""
+	default:
+		return op, ""
+	}
+	for i := 1; i < len(fields)-1; i++ {
+		if strings.EqualFold(fields[i], after) {
+			return op, strings.Trim(fields[i+1], "();")
+		}
+	}
+	return op, ""
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.39.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifica os spans criados pelo próprio código da API
+const instrumentationName = "github.com/ericoliveiras/ecoponto-api"
+
+// Options configura o envio dos traces
+type Options struct {
+	Endpoint    string  // URL do coletor OTLP/HTTP (ex.: http://otel-collector:4318); vazio = traces desligados
+	ServiceName string  // service.name dos spans
+	SampleRatio float64 // Fração dos traces iniciados aqui que são gravados (0 a 1)
+}
+
+// Setup registra o propagador W3C (traceparent/tracestate e baggage) e, se houver
+// endpoint, o exportador OTLP. A função retornada envia os spans pendentes e deve
+// ser chamada no desligamento.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	// 1. O contexto do trace é repassado mesmo com os traces desligados,
+	// para não quebrar o trace de quem chamou a API
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// 2. Exportador OTLP/HTTP (headers extras, ex.: autenticação, vêm de OTEL_EXPORTER_OTLP_HEADERS)
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("criar exportador OTLP: %w", err)
+	}
+
+	// 3. Identificação do serviço
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(semconv.ServiceName(opts.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("montar o resource do OpenTelemetry: %w", err)
+	}
+
+	// 4. Provider com envio em lote; respeita a decisão de amostragem de quem chamou
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Tracer retorna o tracer da API (do provider global, registrado em Setup)
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
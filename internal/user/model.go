@@ -5,7 +5,44 @@ import "time"
 // User representa um usuário admin no banco
 type User struct {
 	ID           string    `db:"id" json:"id"`
+	TenantID     string    `db:"tenant_id" json:"tenant_id"`
 	Email        string    `db:"email" json:"email"`
 	PasswordHash string    `db:"password_hash" json:"-"`
+	Role         string    `db:"role" json:"role"`
+	Ativo        bool      `db:"ativo" json:"ativo"` // Usuários desativados não conseguem entrar
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Papéis (roles) dos usuários, do mais para o menos privilegiado.
+// As permissões de cada um ficam em auth.RolePermissions.
+const (
+	RoleSuperadmin = "superadmin" // Tudo, inclusive excluir e gerenciar usuários
+	RoleEditor     = "editor"     // Cria e edita ecopontos e organizações
+	RoleModerator  = "moderator"  // Corrige dados de ecopontos existentes
+	RoleViewer     = "viewer"     // Apenas consulta a área administrativa
+)
+
+// ListParams são os filtros e a paginação da listagem de usuários
+type ListParams struct {
+	Busca  string // Trecho do email
+	Role   string
+	Ativo  *bool
+	Limite int
+	Offset int
+}
+
+// Page é uma página da listagem de usuários
+type Page struct {
+	Usuarios []User `json:"usuarios"`
+	Total    int    `json:"total"` // Total de usuários que atendem aos filtros
+	Limite   int    `json:"limite"`
+	Offset   int    `json:"offset"`
+}
+
+// Update são os campos alteráveis por um administrador (nil = não altera)
+type Update struct {
+	Email *string
+	Role  *string
+	Ativo *bool
 }
@@ -2,37 +2,165 @@ package user
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 
+	sq "github.com/Masterminds/squirrel"
+	"github.com/ericoliveiras/ecoponto-api/internal/tracing"
 	"github.com/jmoiron/sqlx"
 )
 
+const userColumns = `id, tenant_id, email, password_hash, role, ativo, created_at, updated_at`
+
+// likeEscaper escapa os curingas do LIKE/ILIKE (\ é o escape padrão do Postgres),
+// para a busca tratar "%" e "_" como texto
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type Repository struct {
-	db *sqlx.DB
+	db *tracing.DB // Cada consulta vira um span
 }
 
 func NewRepository(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: tracing.WrapDB(db)}
 }
 
-// Create insere um novo usuário no banco
-func (r *Repository) Create(ctx context.Context, u User) error {
+// Create insere um novo usuário no banco, vinculado ao município u.TenantID
+func (r *Repository) Create(ctx context.Context, u User) (*User, error) {
 	query := `
-		INSERT INTO users (email, password_hash)
-		VALUES ($1, $2)
-		RETURNING id, created_at
-	`
-	return r.db.QueryRowxContext(ctx, query, u.Email, u.PasswordHash).Scan(&u.ID, &u.CreatedAt)
+		INSERT INTO users (tenant_id, email, password_hash, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + userColumns
+	var created User
+	err := r.db.QueryRowxContext(ctx, query, u.TenantID, u.Email, u.PasswordHash, u.Role).StructScan(&created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
 }
 
-// FindByEmail busca um usuário pelo email
-func (r *Repository) FindByEmail(ctx context.Context, email string) (*User, error) {
+// FindByEmail busca um usuário pelo email dentro de um município
+func (r *Repository) FindByEmail(ctx context.Context, tenantID, email string) (*User, error) {
 	var u User
-	query := "SELECT * FROM users WHERE email = $1"
+	query := "SELECT " + userColumns + " FROM users WHERE tenant_id = $1 AND email = $2"
 
-	err := r.db.GetContext(ctx, &u, query, email)
+	err := r.db.GetContext(ctx, &u, query, tenantID, email)
 	if err != nil {
 		return nil, err
 	}
 
 	return &u, nil
 }
+
+// FindByID busca um usuário pelo ID dentro de um município
+func (r *Repository) FindByID(ctx context.Context, tenantID, id string) (*User, error) {
+	var u User
+	query := "SELECT " + userColumns + " FROM users WHERE tenant_id = $1 AND id = $2"
+
+	err := r.db.GetContext(ctx, &u, query, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// List lista os usuários do município, com filtros e paginação
+func (r *Repository) List(ctx context.Context, tenantID string, params ListParams) (*Page, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+	// 1. Monta os filtros (usados na contagem e na página)
+	where := sq.And{sq.Eq{"tenant_id": tenantID}}
+	if params.Busca != "" {
+		where = append(where, sq.ILike{"email": "%" + likeEscaper.Replace(params.Busca) + "%"})
+	}
+	if params.Role != "" {
+		where = append(where, sq.Eq{"role": params.Role})
+	}
+	if params.Ativo != nil {
+		where = append(where, sq.Eq{"ativo": *params.Ativo})
+	}
+
+	// 2. Conta o total
+	countSQL, countArgs, err := psql.Select("COUNT(*)").From("users").Where(where).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	page := Page{Usuarios: make([]User, 0), Limite: params.Limite, Offset: params.Offset}
+	if err := r.db.GetContext(ctx, &page.Total, countSQL, countArgs...); err != nil {
+		return nil, err
+	}
+
+	// 3. Busca a página
+	query, args, err := psql.Select(userColumns).From("users").Where(where).
+		OrderBy("email").
+		Limit(uint64(params.Limite)).
+		Offset(uint64(params.Offset)).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.SelectContext(ctx, &page.Usuarios, query, args...); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// Update altera os campos informados do usuário
+func (r *Repository) Update(ctx context.Context, tenantID, id string, upd Update) (*User, error) {
+	qb := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).
+		Update("users").
+		Set("updated_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"id": id, "tenant_id": tenantID}).
+		Suffix("RETURNING " + userColumns)
+
+	if upd.Email != nil {
+		qb = qb.Set("email", *upd.Email)
+	}
+	if upd.Role != nil {
+		qb = qb.Set("role", *upd.Role)
+	}
+	if upd.Ativo != nil {
+		qb = qb.Set("ativo", *upd.Ativo)
+	}
+
+	query, args, err := qb.ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var u User
+	if err := r.db.QueryRowxContext(ctx, query, args...).StructScan(&u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// UpdatePassword troca o hash da senha do usuário
+func (r *Repository) UpdatePassword(ctx context.Context, tenantID, id, passwordHash string) error {
+	query := `UPDATE users SET password_hash = $3, updated_at = NOW() WHERE tenant_id = $1 AND id = $2`
+	res, err := r.db.ExecContext(ctx, query, tenantID, id, passwordHash)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(res)
+}
+
+// Delete apaga o usuário (as sessões dele são apagadas em cascata)
+func (r *Repository) Delete(ctx context.Context, tenantID, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE tenant_id = $1 AND id = $2`, tenantID, id)
+	if err != nil {
+		return err
+	}
+	return expectOneRow(res)
+}
+
+// expectOneRow retorna sql.ErrNoRows se o comando não afetou nenhuma linha
+func expectOneRow(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}